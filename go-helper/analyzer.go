@@ -5,19 +5,65 @@ import (
 	"html/template"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template/parse"
+	"unicode/utf8"
 )
 
 // TemplateGraph represents the complete analysis result
 type TemplateGraph struct {
-	EntryFile    string              `json:"entryFile"`
-	Templates    map[string]*TmplDef `json:"templates"`
-	Variables    []Variable          `json:"variables"`
-	Dependencies []Dependency        `json:"dependencies"`
-	Htmx         *HtmxInfo           `json:"htmx,omitempty"`
+	EntryFile    string               `json:"entryFile"`
+	Templates    map[string]*TmplDef  `json:"templates"`
+	Variables    []Variable           `json:"variables"`
+	Dependencies []Dependency         `json:"dependencies"`
+	Htmx         *HtmxInfo            `json:"htmx,omitempty"`
+	Alpine       *AlpineInfo          `json:"alpine,omitempty"`
+	Warnings     []*TemplateWarning   `json:"warnings,omitempty"`
+	Ranges       []*RangeInfo         `json:"ranges,omitempty"`
+	ParseTrees   map[string]*TreeNode `json:"parseTrees,omitempty"` // Only with inspect -dump-tree
+
+	ExternalAssets []*ExternalAsset `json:"externalAssets,omitempty"`
+
+	// Cycles lists {{template}} call loops, each as the ordered template names forming
+	// it (["a", "b"] means a calls b and b calls a). A self-recursive template such as
+	// a tree renderer appears as a one-name cycle; it only terminates if its data does.
+	Cycles [][]string `json:"cycles,omitempty"`
+
+	// UnusedTemplates lists {{define}}d templates no other template calls, sorted.
+	// File-level templates and page blocks (content, ...) are roots and never listed.
+	UnusedTemplates []string `json:"unusedTemplates,omitempty"`
+}
+
+// TreeNode is a debug view of a parse.Tree node
+type TreeNode struct {
+	Type     string      `json:"type"`           // Node type, e.g. "FieldNode"
+	Pos      int         `json:"pos"`            // Byte offset in the source
+	Location string      `json:"location"`       // "template:line:col"
+	Text     string      `json:"text,omitempty"` // Source form of the node (truncated)
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// RangeInfo records a {{range}} loop and the variables it declares
+type RangeInfo struct {
+	Collection string `json:"collection"`         // Array path being ranged over, e.g. "Rows"
+	IndexVar   string `json:"indexVar,omitempty"` // "$i" in {{range $i, $item := .Rows}}
+	ValueVar   string `json:"valueVar,omitempty"` // "$item" in {{range $i, $item := .Rows}}
+	Template   string `json:"template"`           // Template (define) containing the loop
+	FilePath   string `json:"filePath"`           // Source file
+}
+
+// TemplateWarning represents a likely problem found by static checks
+type TemplateWarning struct {
+	Type     string `json:"type"`     // "script-escaping", "style-escaping", "missing-block", "event-handler", "missing-template", "htmx-autofire"
+	Message  string `json:"message"`  // Human-readable explanation
+	FilePath string `json:"filePath"` // Source file
+	Line     int    `json:"line"`     // Line number
+	Context  string `json:"context"`  // The offending action
 }
 
 // HtmxDependency represents an HTMX request dependency
@@ -30,6 +76,26 @@ type HtmxDependency struct {
 	FilePath string `json:"filePath"` // Source file
 	Line     int    `json:"line"`     // Line number
 	Context  string `json:"context"`  // Surrounding context
+
+	// Resolved is set when a template looks like it serves this endpoint's fragment:
+	// the URL's last path segment matches a template name or file name
+	Resolved     bool   `json:"resolved"`
+	FragmentName string `json:"fragmentName,omitempty"` // The matching template
+
+	// Parsed from Trigger: requests that fire without user interaction
+	Polling      bool   `json:"polling,omitempty"`      // hx-trigger="every 2s"
+	PollInterval string `json:"pollInterval,omitempty"` // "2s"
+	LoadOnInit   bool   `json:"loadOnInit,omitempty"`   // hx-trigger="load"
+}
+
+// ExternalAsset is a src/href reference to a remote URL (CDN script, remote image, ...)
+type ExternalAsset struct {
+	Tag      string `json:"tag,omitempty"` // Element name, e.g. "script" (empty if the tag spans lines)
+	Attr     string `json:"attr"`          // "src", "href", "srcset", ...
+	URL      string `json:"url"`           // The external URL
+	FilePath string `json:"filePath"`      // Source file
+	Line     int    `json:"line"`          // Line number
+	Context  string `json:"context"`       // Surrounding context
 }
 
 // HtmxInfo contains HTMX analysis results
@@ -39,12 +105,33 @@ type HtmxInfo struct {
 	Dependencies []*HtmxDependency `json:"dependencies"`
 }
 
+// AlpineDirective is one Alpine.js attribute: x-data, x-show, @click (x-on:click),
+// :class (x-bind:class), ...
+type AlpineDirective struct {
+	Directive string `json:"directive"` // Attribute name as written, e.g. "@click.prevent"
+	Value     string `json:"value"`     // Attribute value (the Alpine expression)
+	FilePath  string `json:"filePath"`  // Source file
+	Line      int    `json:"line"`      // Line number
+	Context   string `json:"context"`   // Surrounding context
+}
+
+// AlpineInfo contains Alpine.js analysis results
+type AlpineInfo struct {
+	Detected   bool               `json:"detected"`
+	Version    string             `json:"version,omitempty"`
+	Directives []*AlpineDirective `json:"directives"`
+}
+
 // TmplDef represents a defined template
 type TmplDef struct {
 	Name     string   `json:"name"`
 	FilePath string   `json:"filePath"`
 	IsBlock  bool     `json:"isBlock"`
 	Calls    []string `json:"calls"` // templates it calls
+
+	// Variables lists the data paths read inside this template's body, sorted
+	// (template-local $variables are left out)
+	Variables []string `json:"variables"`
 }
 
 // Variable represents an extracted variable path
@@ -53,7 +140,11 @@ type Variable struct {
 	Type      string      `json:"type"`    // inferred: "string", "bool", "object", "array"
 	Context   string      `json:"context"` // "if", "with", "range", "field"
 	FilePath  string      `json:"filePath"`
+	Line      int         `json:"line,omitempty"`      // 1-based line of the first use in FilePath
+	Col       int         `json:"col,omitempty"`       // 1-based column (in characters) of the first use
 	Suggested interface{} `json:"suggested,omitempty"` // example value
+
+	pos parse.Pos // Byte offset of the first use, converted to Line/Col once analysis ends
 }
 
 // Dependency represents a template dependency
@@ -62,6 +153,7 @@ type Dependency struct {
 	Type     string `json:"type"` // "template", "block", "define"
 	FilePath string `json:"filePath,omitempty"`
 	Required bool   `json:"required"`
+	Missing  bool   `json:"missing,omitempty"` // No analyzed file defines this template
 }
 
 // TemplateAnalyzer analyzes Go templates
@@ -72,58 +164,96 @@ type TemplateAnalyzer struct {
 	dependencies  map[string]*Dependency
 	seenFiles     map[string]bool
 	htmxInfo      *HtmxInfo
+	alpineInfo    *AlpineInfo
 	rangeLiterals map[string][]string // Maps array path to string literals found in its range block
+	warnings      []*TemplateWarning
+	ranges        []*RangeInfo
+	fileDefines   map[string][]string // Maps file path to the template names it defines
+	fileInvokes   map[string][]string // Maps file path to the template names it calls
+	blockDefaults map[string]bool     // Template names declared with {{block}} (which carry a default)
+	externalRefs  []*ExternalAsset    // src/href references to remote URLs
+	dumpTree      bool                // Include parse trees in the graph for debugging
+	parseTrees    map[string]*TreeNode
+	pageBlocks    []string          // Block names that mark a file as a page (default: content)
+	fileContents  map[string]string // Source of each analyzed file, for offset → line/column
+
+	// The template being walked and the paths it reads so far, for TmplDef.Variables
+	currentDef  *TmplDef
+	currentVars map[string]bool
+
+	// Action delimiters (default {{ }}) and the source-scanning patterns built from them
+	leftDelim  string
+	rightDelim string
+	actionRe   *regexp.Regexp // Any action; group 1 is its inner text
+	blockRe    *regexp.Regexp // {{block "name" ...}}; group 1 is the name
 }
 
+// defaultPageBlocks is the page-defining block name when none are configured
+var defaultPageBlocks = []string{"content"}
+
 // getAnalyzerFuncs returns stub functions so the analyzer can parse templates
 // that use custom helper functions. These don't need real implementations -
 // they just need to exist so parsing succeeds.
 func getAnalyzerFuncs() template.FuncMap {
 	// Stub function that accepts any number of args and returns empty interface
 	stub := func(args ...interface{}) interface{} { return nil }
-	stubBool := func(args ...interface{}) bool { return false }
-	stubInt := func(args ...interface{}) int { return 0 }
-	stubStr := func(args ...interface{}) string { return "" }
-	stubSlice := func(args ...interface{}) []int { return nil }
-
-	return template.FuncMap{
-		// Math helpers
-		"add": stubInt, "sub": stubInt, "mul": stubInt, "div": stubInt, "mod": stubInt,
-		// String helpers
-		"upper": stubStr, "lower": stubStr, "title": stubStr, "trim": stubStr,
-		"contains": stubBool, "hasPrefix": stubBool, "hasSuffix": stubBool,
-		"replace": stubStr, "split": stub, "join": stubStr,
-		// Array/slice helpers
-		"isLast": stubBool, "isFirst": stubBool, "seq": stubSlice,
-		// Safe output helpers
-		"safeHTML": stub, "safeJS": stub, "safeCSS": stub, "safeURL": stub,
-		// Conditional helpers
-		"default": stub, "ternary": stub,
-		// Common additional helpers users might have
-		"dict": stub, "list": stub, "slice": stub, "append": stub,
-		"now": stub, "date": stubStr, "dateFormat": stubStr,
-		"json": stubStr, "jsonify": stubStr, "toJSON": stubStr,
-		"html": stubStr, "urlquery": stubStr, "printf": stubStr,
-		"first": stub, "last": stub, "rest": stub, "reverse": stub,
-		"sort": stub, "uniq": stub, "shuffle": stub,
-		"len": stubInt, "isset": stubBool, "empty": stubBool,
-		"pluralize": stubStr, "singularize": stubStr,
-		"markdown": stub, "markdownify": stub,
-		"truncate": stubStr, "wordwrap": stubStr,
-		"attr": stub, "class": stubStr,
+
+	// Every helper the dev server knows (a superset of render's), so parsing never
+	// fails on a func the runtime supports
+	funcs := template.FuncMap{}
+	for name := range (&DevServer{}).funcMap() {
+		funcs[name] = stub
 	}
+
+	// Common additional helpers users might have from other template engines
+	for _, name := range []string{
+		"json", "append",
+		"first", "last", "rest", "reverse", "sort", "uniq", "shuffle",
+		"isset", "empty", "attr", "class",
+	} {
+		funcs[name] = stub
+	}
+	return funcs
 }
 
 func NewTemplateAnalyzer(workspace string) *TemplateAnalyzer {
-	return &TemplateAnalyzer{
+	a := &TemplateAnalyzer{
 		workspace:     workspace,
 		templates:     make(map[string]*TmplDef),
 		variables:     make(map[string]*Variable),
 		dependencies:  make(map[string]*Dependency),
 		seenFiles:     make(map[string]bool),
 		htmxInfo:      &HtmxInfo{Dependencies: []*HtmxDependency{}},
+		alpineInfo:    &AlpineInfo{Directives: []*AlpineDirective{}},
+		fileContents:  make(map[string]string),
 		rangeLiterals: make(map[string][]string),
+		fileDefines:   make(map[string][]string),
+		fileInvokes:   make(map[string][]string),
+		blockDefaults: make(map[string]bool),
+		pageBlocks:    defaultPageBlocks,
+	}
+	a.SetDelims("", "")
+	return a
+}
+
+// SetDelims sets the action delimiters used to parse and scan templates, e.g. "[["
+// and "]]" for templates that share a page with Vue. Empty values mean {{ and }}.
+func (a *TemplateAnalyzer) SetDelims(left, right string) {
+	a.leftDelim, a.rightDelim = defaultDelims(left, right)
+	l, r := regexp.QuoteMeta(a.leftDelim), regexp.QuoteMeta(a.rightDelim)
+	a.actionRe = regexp.MustCompile(`(?s)` + l + `-?\s*(.*?)\s*-?` + r)
+	a.blockRe = regexp.MustCompile(l + `-?\s*block\s+"([^"]+)"`)
+}
+
+// defaultDelims fills in Go's default {{ }} for empty delimiters
+func defaultDelims(left, right string) (string, string) {
+	if left == "" {
+		left = "{{"
+	}
+	if right == "" {
+		right = "}}"
 	}
+	return left, right
 }
 
 func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateGraph, error) {
@@ -148,6 +278,14 @@ func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateG
 		}
 	}
 
+	// Report pages that leave a layout slot without content
+	a.checkLayoutBlocks(entryFile)
+
+	// Resolve each variable's byte offset to a line and column in its file
+	for _, v := range a.variables {
+		v.Line, v.Col = a.lineCol(v.FilePath, v.pos)
+	}
+
 	// Convert maps to slices and deduplicate redundant variables
 	// Priority: eq-number, eq-string, gt-number (comparison contexts) > generic contexts
 	vars := make([]Variable, 0, len(a.variables))
@@ -181,8 +319,11 @@ func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateG
 	priorityContexts := map[string]int{
 		"eq-number":        10, // Highest priority - explicit numeric comparison
 		"gt-number":        10,
+		"eq-bool":          10,
 		"eq-string":        9, // String comparison
 		"range-collection": 8, // Array being ranged over
+		"index-array":      8, // Collection read with index .X 0
+		"index-object":     8, // Map read with index .X "key"
 		"range":            5, // Inside a range
 		"if":               3,
 		"with":             3,
@@ -230,8 +371,8 @@ func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateG
 	for _, v := range pathToVar {
 		skip := false
 
-		// Never skip array variables that are the range collection itself
-		if v.Context == "range-collection" && v.Type == "array" {
+		// Never skip array variables that are ranged over or indexed
+		if (v.Context == "range-collection" || v.Context == "index-array") && v.Type == "array" {
 			skip = false // Keep these
 		} else if !strings.Contains(v.Path, "[0].") && !strings.Contains(v.Path, ".") {
 			// Skip standalone fields that are already represented as array item fields
@@ -264,10 +405,34 @@ func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateG
 		}
 	}
 
+	// Map iteration order is random; sort so output is stable across runs
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].Path != vars[j].Path {
+			return vars[i].Path < vars[j].Path
+		}
+		return vars[i].Context < vars[j].Context
+	})
+
 	deps := make([]Dependency, 0, len(a.dependencies))
 	for _, d := range a.dependencies {
+		if _, defined := a.templates[d.Name]; !defined {
+			d.Missing = true
+		}
 		deps = append(deps, *d)
 	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		if deps[i].Type != deps[j].Type {
+			return deps[i].Type < deps[j].Type
+		}
+		return deps[i].FilePath < deps[j].FilePath
+	})
+	a.checkMissingTemplates()
+
+	// Tie each HTMX endpoint to the template that could render its fragment
+	a.resolveHtmxFragments()
 
 	// Set HTMX detected flag
 	if len(a.htmxInfo.Dependencies) > 0 || a.htmxInfo.Version != "" {
@@ -286,6 +451,15 @@ func (a *TemplateAnalyzer) Analyze(entryFile string, files []string) (*TemplateG
 		Variables:    vars,
 		Dependencies: deps,
 		Htmx:         a.htmxInfo,
+		Alpine:       a.alpineInfo,
+		Warnings:     a.warnings,
+		Ranges:       a.ranges,
+		ParseTrees:   a.parseTrees,
+
+		ExternalAssets: a.externalRefs,
+		Cycles:         a.findCycles(),
+
+		UnusedTemplates: a.findUnusedTemplates(),
 	}, nil
 }
 
@@ -301,16 +475,36 @@ func (a *TemplateAnalyzer) analyzeFile(filePath string) error {
 	}
 
 	contentStr := string(content)
+	a.fileContents[filePath] = contentStr
 
 	// Detect HTMX usage
 	a.detectHtmx(filePath, contentStr)
 
+	// Detect Alpine.js usage
+	a.detectAlpine(filePath, contentStr)
+
+	// List src/href references to remote URLs
+	a.detectExternalAssets(filePath, contentStr)
+
+	// Flag actions inside <script>/<style> that html/template will escape unexpectedly
+	a.detectEscapingIssues(filePath, contentStr)
+
+	// Flag template data written into onclick=/hx-on: handlers for XSS review
+	a.detectEventHandlers(filePath, contentStr)
+
 	// Parse the template with helper function stubs so parsing doesn't fail
-	tmpl, err := template.New(filepath.Base(filePath)).Funcs(getAnalyzerFuncs()).Parse(contentStr)
+	tmpl, err := template.New(filepath.Base(filePath)).Delims(a.leftDelim, a.rightDelim).Funcs(getAnalyzerFuncs()).Parse(contentStr)
 	if err != nil {
 		return fmt.Errorf("parse error in %s: %v", filePath, err)
 	}
 
+	// {{block "name"}} parses into a define plus a call, so find blocks in the source
+	blocks := make(map[string]bool)
+	for _, m := range a.blockRe.FindAllStringSubmatch(contentStr, -1) {
+		blocks[m[1]] = true
+		a.blockDefaults[m[1]] = true
+	}
+
 	// Walk the parse tree
 	for _, t := range tmpl.Templates() {
 		if t.Tree == nil || t.Tree.Root == nil {
@@ -320,16 +514,246 @@ func (a *TemplateAnalyzer) analyzeFile(filePath string) error {
 		def := &TmplDef{
 			Name:     t.Name(),
 			FilePath: filePath,
+			IsBlock:  blocks[t.Name()],
 			Calls:    []string{},
+
+			Variables: []string{},
 		}
 
+		a.currentDef, a.currentVars = def, make(map[string]bool)
 		a.walkNode(t.Tree.Root, filePath, def, "")
+		sort.Strings(def.Variables)
+		a.currentDef, a.currentVars = nil, nil
 		a.templates[t.Name()] = def
+
+		if a.dumpTree {
+			if a.parseTrees == nil {
+				a.parseTrees = make(map[string]*TreeNode)
+			}
+			a.parseTrees[t.Name()] = dumpParseNode(t.Tree, t.Tree.Root)
+		}
+
+		if t.Name() != tmpl.Name() {
+			a.fileDefines[filePath] = append(a.fileDefines[filePath], t.Name())
+		}
+		a.fileInvokes[filePath] = append(a.fileInvokes[filePath], def.Calls...)
 	}
 
 	return nil
 }
 
+// checkLayoutBlocks cross-references the templates the entry layout invokes against the
+// templates each page defines. Pages are files that define one of the page blocks (the
+// same rule the dev server uses); definitions in any other file are shared by every page. A page that
+// doesn't define a slot the layout invokes without a {{block}} default renders it empty.
+func (a *TemplateAnalyzer) checkLayoutBlocks(entryFile string) {
+	layoutDefines := make(map[string]bool)
+	for _, name := range a.fileDefines[entryFile] {
+		layoutDefines[name] = true
+	}
+
+	// Slots: names the layout calls that it doesn't define itself (blocks define their default)
+	var slots []string
+	seenSlot := make(map[string]bool)
+	for _, name := range a.fileInvokes[entryFile] {
+		if layoutDefines[name] || a.blockDefaults[name] || seenSlot[name] {
+			continue
+		}
+		seenSlot[name] = true
+		slots = append(slots, name)
+	}
+	if len(slots) == 0 {
+		return
+	}
+
+	var pages []string
+	shared := make(map[string]bool)
+	for file, names := range a.fileDefines {
+		if file == entryFile {
+			continue
+		}
+		isPage := false
+		for _, name := range names {
+			for _, block := range a.pageBlocks {
+				if name == block {
+					isPage = true
+				}
+			}
+		}
+		if isPage {
+			pages = append(pages, file)
+			continue
+		}
+		for _, name := range names {
+			shared[name] = true
+		}
+	}
+	sort.Strings(pages)
+
+	for _, page := range pages {
+		defined := make(map[string]bool)
+		for _, name := range a.fileDefines[page] {
+			defined[name] = true
+		}
+		for _, slot := range slots {
+			if defined[slot] || shared[slot] {
+				continue
+			}
+			a.warnings = append(a.warnings, &TemplateWarning{
+				Type: "missing-block",
+				Message: fmt.Sprintf("%s does not define %q, which %s invokes without a {{block}} default",
+					filepath.Base(page), slot, filepath.Base(entryFile)),
+				FilePath: page,
+				Context:  fmt.Sprintf(`{{template %q}}`, slot),
+			})
+		}
+	}
+}
+
+// checkMissingTemplates warns about each {{template}} call whose name no analyzed file
+// defines (often a typo such as "parital/nav"), reported against the calling template.
+func (a *TemplateAnalyzer) checkMissingTemplates() {
+	names := make([]string, 0, len(a.templates))
+	for name := range a.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := a.templates[name]
+		reported := make(map[string]bool)
+		for _, call := range def.Calls {
+			if _, defined := a.templates[call]; defined || reported[call] {
+				continue
+			}
+			reported[call] = true
+			a.warnings = append(a.warnings, &TemplateWarning{
+				Type:     "missing-template",
+				Message:  fmt.Sprintf("%q calls template %q, which no analyzed file defines", name, call),
+				FilePath: def.FilePath,
+				Context:  fmt.Sprintf(`{{template %q}}`, call),
+			})
+		}
+	}
+}
+
+// findUnusedTemplates returns the defined templates that no other template calls.
+// A file's own template is its root and page blocks are filled by the layout, so
+// neither counts; a template that only calls itself is still unused.
+func (a *TemplateAnalyzer) findUnusedTemplates() []string {
+	defined := make(map[string]bool)
+	for _, names := range a.fileDefines {
+		for _, name := range names {
+			defined[name] = true
+		}
+	}
+	for _, block := range a.pageBlocks {
+		delete(defined, block)
+	}
+
+	for name, def := range a.templates {
+		for _, call := range def.Calls {
+			if call != name {
+				delete(defined, call)
+			}
+		}
+	}
+
+	unused := make([]string, 0, len(defined))
+	for name := range defined {
+		unused = append(unused, name)
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// findCycles runs a depth-first search over the template call graph and returns each
+// loop it closes, rotated to start at its smallest name so the output is stable.
+// Calls to templates that aren't defined are dead ends.
+func (a *TemplateAnalyzer) findCycles() [][]string {
+	names := make([]string, 0, len(a.templates))
+	for name := range a.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+	seen := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = onStack
+		stack = append(stack, name)
+		def := a.templates[name]
+		calls := append([]string(nil), def.Calls...)
+		sort.Strings(calls)
+		for _, call := range calls {
+			if _, defined := a.templates[call]; !defined {
+				continue
+			}
+			switch state[call] {
+			case unvisited:
+				visit(call)
+			case onStack:
+				// Back edge: the loop is the stack from call to the top
+				start := len(stack) - 1
+				for stack[start] != call {
+					start--
+				}
+				cycle := append([]string(nil), stack[start:]...)
+				first := 0
+				for i := range cycle {
+					if cycle[i] < cycle[first] {
+						first = i
+					}
+				}
+				cycle = append(cycle[first:], cycle[:first]...)
+				if key := strings.Join(cycle, "\x00"); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
+
+// seenVariable reports whether a variable key ("path::context") was already recorded,
+// and attributes its path to the template being walked either way
+func (a *TemplateAnalyzer) seenVariable(key string) bool {
+	path, _, _ := strings.Cut(key, "::")
+	if a.currentDef != nil && !strings.HasPrefix(path, "$") && !a.currentVars[path] {
+		a.currentVars[path] = true
+		a.currentDef.Variables = append(a.currentDef.Variables, path)
+	}
+	_, exists := a.variables[key]
+	return exists
+}
+
+// hasNestedVariable reports whether a recorded variable reads a field or item of path
+func (a *TemplateAnalyzer) hasNestedVariable(path string) bool {
+	for _, v := range a.variables {
+		if strings.HasPrefix(v.Path, path+".") || strings.HasPrefix(v.Path, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *TemplateAnalyzer) walkNode(node parse.Node, filePath string, def *TmplDef, context string) {
 	if node == nil {
 		return
@@ -368,6 +792,12 @@ func (a *TemplateAnalyzer) walkNode(node parse.Node, filePath string, def *TmplD
 			}
 		}
 
+		// Inside {{with .User}}, {{range .Tags}} iterates User.Tags
+		withScoped := arrayPath != "" && strings.HasPrefix(context, "with:")
+		if withScoped {
+			arrayPath, _ = scopePath(context, arrayPath)
+		}
+
 		// Extract string literals from this range block BEFORE processing the pipe
 		// This ensures the literals are available when we create the variable
 		if arrayPath != "" {
@@ -376,7 +806,34 @@ func (a *TemplateAnalyzer) walkNode(node parse.Node, filePath string, def *TmplD
 
 		// NOW extract the array variable with special "range-collection" context
 		// At this point, rangeLiterals[arrayPath] is populated
-		a.walkPipe(n.Pipe, filePath, "range-collection")
+		if withScoped {
+			key := arrayPath + "::range-collection"
+			if !a.seenVariable(key) {
+				a.variables[key] = &Variable{
+					Path:      arrayPath,
+					Type:      "array",
+					Context:   "range-collection",
+					FilePath:  filePath,
+					pos:       n.Pipe.Position(),
+					Suggested: a.suggestValue("array", arrayPath),
+				}
+			}
+		} else {
+			a.walkPipe(n.Pipe, filePath, "range-collection")
+		}
+
+		// Record the loop with its declared index/value variables
+		if arrayPath != "" {
+			info := &RangeInfo{Collection: arrayPath, Template: def.Name, FilePath: filePath}
+			switch decl := n.Pipe.Decl; len(decl) {
+			case 1:
+				info.ValueVar = decl[0].Ident[0]
+			case 2:
+				info.IndexVar = decl[0].Ident[0]
+				info.ValueVar = decl[1].Ident[0]
+			}
+			a.ranges = append(a.ranges, info)
+		}
 
 		// Pass "range:ArrayName" as context so children know they're inside this array
 		rangeContext := "range"
@@ -391,10 +848,49 @@ func (a *TemplateAnalyzer) walkNode(node parse.Node, filePath string, def *TmplD
 		}
 
 	case *parse.WithNode:
-		a.walkPipe(n.Pipe, filePath, "with")
-		a.walkNode(n.List, filePath, def, "with")
+		// The with subject rebinds dot, so fields inside are relative to it —
+		// pass "with:User" as context, stacking onto any enclosing range/with scope
+		subject := ""
+		indexed := false
+		if n.Pipe != nil && len(n.Pipe.Cmds) > 0 && len(n.Pipe.Cmds[0].Args) == 1 {
+			if field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) > 0 {
+				subject, _ = scopePath(context, strings.Join(field.Ident, "."))
+			}
+		} else if n.Pipe != nil && len(n.Pipe.Cmds) == 1 {
+			// {{with index .Items 0}} rebinds dot to an array item: Items[0]
+			if arrayPath, ok := a.indexedItemPath(n.Pipe.Cmds[0], context); ok {
+				a.walkPipe(n.Pipe, filePath, context)
+				subject, indexed = arrayPath+"[0]", true
+			}
+		}
+
+		if indexed {
+			a.walkNode(n.List, filePath, def, "with:"+subject)
+		} else if subject != "" {
+			key := subject + "::with"
+			if !a.seenVariable(key) {
+				a.variables[key] = &Variable{
+					Path:      subject,
+					Type:      a.inferType("with", subject),
+					Context:   "with",
+					FilePath:  filePath,
+					pos:       n.Pipe.Position(),
+					Suggested: a.suggestValue(a.inferType("with", subject), subject),
+				}
+			}
+			a.walkNode(n.List, filePath, def, "with:"+subject)
+			// Fields read inside make the subject an object, not a truthy leaf
+			if v := a.variables[key]; v.Type != "object" && a.hasNestedVariable(subject) {
+				v.Type = "object"
+				v.Suggested = a.suggestValue("object", subject)
+			}
+		} else {
+			a.walkPipe(n.Pipe, filePath, "with")
+			a.walkNode(n.List, filePath, def, "with")
+		}
+		// {{else}} runs with the outer dot
 		if n.ElseList != nil {
-			a.walkNode(n.ElseList, filePath, def, "with")
+			a.walkNode(n.ElseList, filePath, def, context)
 		}
 
 	case *parse.TemplateNode:
@@ -434,12 +930,16 @@ func (a *TemplateAnalyzer) walkPipe(pipe *parse.PipeNode, filePath, context stri
 		if len(cmd.Args) >= 3 {
 			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
 				switch ident.Ident {
+				case "index":
+					// index .Items 0 / index .Labels "key" - type the collection
+					a.extractIndexAccess(cmd.Args[1:], filePath, context)
+					continue
 				case "eq", "ne":
 					// String comparison - look for field + string literal pairs
 					a.extractEqComparison(cmd.Args[1:], filePath, context)
 					continue
-				case "gt", "lt", "ge", "le":
-					// Numeric comparison - look for field + number literal pairs
+				case "gt", "lt", "ge", "le", "add", "sub", "mul", "div", "mod", "addf", "subf", "mulf", "divf":
+					// Numeric comparison or arithmetic - operands must be numbers
 					a.extractNumericComparison(cmd.Args[1:], filePath, context)
 					continue
 				}
@@ -459,9 +959,10 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 	var fields []*parse.FieldNode
 	var chainNodes []*parse.ChainNode
 	var stringLiterals []string
-	var numberLiterals []int64
+	var numberLiterals []interface{} // int64 or float64, as written in the template
+	var boolLiterals []bool
 
-	// Collect all field nodes, chain nodes, string literals, and number literals from the comparison
+	// Collect all field nodes, chain nodes, and string/number/bool literals from the comparison
 	for _, arg := range args {
 		switch n := arg.(type) {
 		case *parse.FieldNode:
@@ -474,8 +975,10 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 			if n.IsInt {
 				numberLiterals = append(numberLiterals, n.Int64)
 			} else if n.IsFloat {
-				numberLiterals = append(numberLiterals, int64(n.Float64))
+				numberLiterals = append(numberLiterals, n.Float64)
 			}
+		case *parse.BoolNode:
+			boolLiterals = append(boolLiterals, n.True)
 		case *parse.PipeNode:
 			// Recursively handle nested pipes
 			a.walkPipe(n, filePath, context)
@@ -484,6 +987,7 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 
 	// Determine the comparison type based on what literals we found
 	isNumericComparison := len(numberLiterals) > 0 && len(stringLiterals) == 0
+	isBoolComparison := len(boolLiterals) > 0 && len(stringLiterals) == 0 && len(numberLiterals) == 0
 
 	// For each field being compared, extract with appropriate type
 	for _, field := range fields {
@@ -492,19 +996,17 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 			continue
 		}
 
-		// Handle range context prefix
-		if strings.HasPrefix(context, "range:") {
-			arrayName := strings.TrimPrefix(context, "range:")
-			path = arrayName + "[0]." + path
-		} else if context == "range" {
+		// Handle range/with scope prefix
+		path, ok := scopePath(context, path)
+		if !ok {
 			continue // Skip if we can't determine array name
 		}
 
 		if isNumericComparison {
 			// Numeric comparison: eq .Field 30
 			key := path + "::eq-number"
-			if _, exists := a.variables[key]; !exists {
-				suggested := int64(0)
+			if !a.seenVariable(key) {
+				var suggested interface{} = int64(0)
 				if len(numberLiterals) > 0 {
 					suggested = numberLiterals[0]
 				}
@@ -514,13 +1016,27 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 					Type:      "number",
 					Context:   "eq-number",
 					FilePath:  filePath,
+					pos:       field.Position(),
 					Suggested: suggested,
 				}
 			}
+		} else if isBoolComparison {
+			// Boolean comparison: eq .Enabled true
+			key := path + "::eq-bool"
+			if !a.seenVariable(key) {
+				a.variables[key] = &Variable{
+					Path:      path,
+					Type:      "bool",
+					Context:   "eq-bool",
+					FilePath:  filePath,
+					pos:       field.Position(),
+					Suggested: boolLiterals[0],
+				}
+			}
 		} else {
 			// String comparison: eq .Field "value"
 			key := path + "::eq-string"
-			if _, exists := a.variables[key]; !exists {
+			if !a.seenVariable(key) {
 				suggested := ""
 				if len(stringLiterals) > 0 {
 					suggested = stringLiterals[0]
@@ -531,6 +1047,7 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 					Type:      "string",
 					Context:   "eq-string",
 					FilePath:  filePath,
+					pos:       field.Position(),
 					Suggested: suggested,
 				}
 			}
@@ -546,8 +1063,8 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 
 			if isNumericComparison {
 				key := path + "::eq-number"
-				if _, exists := a.variables[key]; !exists {
-					suggested := int64(0)
+				if !a.seenVariable(key) {
+					var suggested interface{} = int64(0)
 					if len(numberLiterals) > 0 {
 						suggested = numberLiterals[0]
 					}
@@ -557,13 +1074,26 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 						Type:      "number",
 						Context:   "eq-number",
 						FilePath:  filePath,
+						pos:       chain.Position(),
 						Suggested: suggested,
 					}
 				}
+			} else if isBoolComparison {
+				key := path + "::eq-bool"
+				if !a.seenVariable(key) {
+					a.variables[key] = &Variable{
+						Path:      path,
+						Type:      "bool",
+						Context:   "eq-bool",
+						FilePath:  filePath,
+						pos:       chain.Position(),
+						Suggested: boolLiterals[0],
+					}
+				}
 			} else {
 				// Chain nodes with $ prefix are root-level, so don't add range prefix
 				key := path + "::eq-string"
-				if _, exists := a.variables[key]; !exists {
+				if !a.seenVariable(key) {
 					suggested := ""
 					if len(stringLiterals) > 0 {
 						suggested = stringLiterals[0]
@@ -574,6 +1104,7 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 						Type:      "string",
 						Context:   "eq-string",
 						FilePath:  filePath,
+						pos:       chain.Position(),
 						Suggested: suggested,
 					}
 				}
@@ -587,7 +1118,9 @@ func (a *TemplateAnalyzer) extractEqComparison(args []parse.Node, filePath, cont
 			if _, ok := arg.(*parse.StringNode); !ok {
 				if _, ok := arg.(*parse.ChainNode); !ok {
 					if _, ok := arg.(*parse.NumberNode); !ok {
-						a.extractVariables(arg, filePath, context)
+						if _, ok := arg.(*parse.BoolNode); !ok {
+							a.extractVariables(arg, filePath, context)
+						}
 					}
 				}
 			}
@@ -626,17 +1159,15 @@ func (a *TemplateAnalyzer) extractNumericComparison(args []parse.Node, filePath,
 			continue
 		}
 
-		// Handle range context prefix
-		if strings.HasPrefix(context, "range:") {
-			arrayName := strings.TrimPrefix(context, "range:")
-			path = arrayName + "[0]." + path
-		} else if context == "range" {
+		// Handle range/with scope prefix
+		path, ok := scopePath(context, path)
+		if !ok {
 			continue // Skip if we can't determine array name
 		}
 
 		// Use "gt-number" context to indicate this is a numeric comparison
 		key := path + "::gt-number"
-		if _, exists := a.variables[key]; !exists {
+		if !a.seenVariable(key) {
 			// Use the first number literal as suggested value, or 0
 			var suggested int64 = 0
 			if len(numberLiterals) > 0 {
@@ -648,6 +1179,7 @@ func (a *TemplateAnalyzer) extractNumericComparison(args []parse.Node, filePath,
 				Type:      "number", // Always number when compared with gt/lt/ge/le
 				Context:   "gt-number",
 				FilePath:  filePath,
+				pos:       field.Position(),
 				Suggested: suggested,
 			}
 		}
@@ -669,13 +1201,15 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 		// e.g., .User.Name
 		path := strings.Join(n.Ident, ".")
 		if path != "" {
-			// Check if we're inside a range with an array name
+			// Check if we're inside a range with an array name or a with subject
 			if strings.HasPrefix(context, "range:") {
-				// Extract the array name from context
-				arrayName := strings.TrimPrefix(context, "range:")
 				// Prefix the field path with ArrayName[0].
-				path = arrayName + "[0]." + path
+				path, _ = scopePath(context, path)
 				context = "range" // Normalize context for type inference
+			} else if strings.HasPrefix(context, "with:") {
+				// Prefix the field path with the with subject (User.Name)
+				path, _ = scopePath(context, path)
+				context = "with"
 			} else if context == "range" {
 				// Inside a range but we couldn't determine the array name
 				// Skip this variable to avoid incorrect top-level extraction
@@ -683,7 +1217,7 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 			}
 
 			key := path + "::" + context
-			if _, exists := a.variables[key]; !exists {
+			if !a.seenVariable(key) {
 				varType := a.inferType(context, path)
 				suggested := a.suggestValue(varType, path)
 
@@ -692,6 +1226,7 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 					Type:      varType,
 					Context:   context,
 					FilePath:  filePath,
+					pos:       n.Position(),
 					Suggested: suggested,
 				}
 			}
@@ -701,17 +1236,39 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 		// e.g., $var
 		for _, ident := range n.Ident {
 			key := "$" + ident + "::" + context
-			if _, exists := a.variables[key]; !exists {
+			if !a.seenVariable(key) {
 				a.variables[key] = &Variable{
 					Path:     "$" + ident,
 					Type:     "variable",
 					Context:  context,
 					FilePath: filePath,
+					pos:      n.Position(),
 				}
 			}
 		}
 
 	case *parse.ChainNode:
+		// (index .Items 0).Name reads a field of an array item: Items[0].Name
+		if base, ok := n.Node.(*parse.PipeNode); ok && len(base.Cmds) == 1 && len(n.Field) > 0 {
+			if arrayPath, ok := a.indexedItemPath(base.Cmds[0], context); ok {
+				path := arrayPath + "[0]." + strings.Join(n.Field, ".")
+				key := path + "::range"
+				if !a.seenVariable(key) {
+					varType := a.inferType("range", path)
+					a.variables[key] = &Variable{
+						Path:      path,
+						Type:      varType,
+						Context:   "range",
+						FilePath:  filePath,
+						pos:       n.Position(),
+						Suggested: a.suggestValue(varType, path),
+					}
+				}
+				a.walkPipe(base, filePath, context)
+				return
+			}
+		}
+
 		// Chain nodes like $.CurrentContext.ID have a base node and field chain
 		// The $ means root context, so we extract the field path without range prefix
 		if len(n.Field) > 0 {
@@ -719,7 +1276,7 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 			if path != "" {
 				// Chain nodes ($.X) are always root-level, even inside range blocks
 				key := path + "::chain"
-				if _, exists := a.variables[key]; !exists {
+				if !a.seenVariable(key) {
 					varType := a.inferType("chain", path)
 					suggested := a.suggestValue(varType, path)
 
@@ -728,6 +1285,7 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 						Type:      varType,
 						Context:   "chain",
 						FilePath:  filePath,
+						pos:       n.Position(),
 						Suggested: suggested,
 					}
 				}
@@ -745,6 +1303,92 @@ func (a *TemplateAnalyzer) extractVariables(node parse.Node, filePath, context s
 	}
 }
 
+// lineCol converts a byte offset in an analyzed file to a 1-based line and column.
+// The column counts characters, not bytes, so it lines up in editors. It returns
+// zeros when the offset is unknown.
+func (a *TemplateAnalyzer) lineCol(filePath string, pos parse.Pos) (int, int) {
+	content, ok := a.fileContents[filePath]
+	if !ok || pos <= 0 || int(pos) > len(content) {
+		return 0, 0
+	}
+	before := content[:pos]
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// indexedItemPath recognizes "index .Items <int>" and returns the (scoped) array path.
+// Map lookups (string keys) and non-field collections report false.
+func (a *TemplateAnalyzer) indexedItemPath(cmd *parse.CommandNode, context string) (string, bool) {
+	if len(cmd.Args) != 3 {
+		return "", false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || ident.Ident != "index" {
+		return "", false
+	}
+	field, ok := cmd.Args[1].(*parse.FieldNode)
+	if !ok || len(field.Ident) == 0 {
+		return "", false
+	}
+	if num, ok := cmd.Args[2].(*parse.NumberNode); !ok || !num.IsInt {
+		return "", false
+	}
+	return scopePath(context, strings.Join(field.Ident, "."))
+}
+
+// extractIndexAccess types the collection passed to the index builtin: an integer
+// index makes it an array (index .Items 0), a string key an object (index .Labels "en").
+// Other arguments are extracted as ordinary variables.
+func (a *TemplateAnalyzer) extractIndexAccess(args []parse.Node, filePath, context string) {
+	field, isField := args[0].(*parse.FieldNode)
+	varType := ""
+	switch key := args[1].(type) {
+	case *parse.NumberNode:
+		if key.IsInt {
+			varType = "array"
+		}
+	case *parse.StringNode:
+		varType = "object"
+	}
+
+	start := 0
+	if isField && len(field.Ident) > 0 && varType != "" {
+		if path, ok := scopePath(context, strings.Join(field.Ident, ".")); ok {
+			ctx := "index-" + varType
+			key := path + "::" + ctx
+			if !a.seenVariable(key) {
+				a.variables[key] = &Variable{
+					Path:      path,
+					Type:      varType,
+					Context:   ctx,
+					FilePath:  filePath,
+					pos:       field.Position(),
+					Suggested: a.suggestValue(varType, path),
+				}
+			}
+			start = 1
+		}
+	}
+	for _, arg := range args[start:] {
+		a.extractVariables(arg, filePath, context)
+	}
+}
+
+// scopePath resolves a field path against the enclosing scope: "range:Items" gives
+// Items[0].path and "with:User" gives User.path. It reports false inside a range whose
+// collection couldn't be determined.
+func scopePath(context, path string) (string, bool) {
+	switch {
+	case strings.HasPrefix(context, "range:"):
+		return strings.TrimPrefix(context, "range:") + "[0]." + path, true
+	case strings.HasPrefix(context, "with:"):
+		return strings.TrimPrefix(context, "with:") + "." + path, true
+	case context == "range":
+		return "", false
+	}
+	return path, true
+}
+
 // extractRangeLiterals extracts string literals from comparison operations in a range block
 // This helps populate arrays with meaningful test data (e.g., ["google", "github", "email"])
 func (a *TemplateAnalyzer) extractRangeLiterals(node parse.Node, arrayPath string) {
@@ -1004,10 +1648,13 @@ func (a *TemplateAnalyzer) detectHtmx(filePath string, content string) {
 					dep.Swap = swapMatch[1]
 				}
 
-				// Extract hx-trigger if present in context
+				// Extract hx-trigger from the element itself: unlike hx-target it isn't
+				// inherited, so a neighbour's trigger must not be picked up
 				triggerRe := regexp.MustCompile(`hx-trigger\s*=\s*["']([^"']+)["']`)
-				if triggerMatch := triggerRe.FindStringSubmatch(contextLines); len(triggerMatch) > 1 {
+				tag := enclosingTag(lines, lineNum, strings.Index(line, match[0]))
+				if triggerMatch := triggerRe.FindStringSubmatch(tag); len(triggerMatch) > 1 {
 					dep.Trigger = triggerMatch[1]
+					classifyHtmxTrigger(dep)
 				}
 
 				// Get some context (trimmed line)
@@ -1018,7 +1665,396 @@ func (a *TemplateAnalyzer) detectHtmx(filePath string, content string) {
 
 				a.htmxInfo.Dependencies = append(a.htmxInfo.Dependencies, dep)
 				a.htmxInfo.Detected = true
+
+				if dep.Polling || dep.LoadOnInit {
+					when := "as soon as the page loads"
+					if dep.Polling {
+						when = "every " + dep.PollInterval
+					}
+					a.warnings = append(a.warnings, &TemplateWarning{
+						Type:     "htmx-autofire",
+						Message:  fmt.Sprintf("%s %s fires automatically %s, without user interaction", attr, dep.URL, when),
+						FilePath: filePath,
+						Line:     dep.Line,
+						Context:  dep.Context,
+					})
+				}
 			}
 		}
 	}
 }
+
+// enclosingTag returns the text of the start tag containing column col of
+// lines[lineNum], from its "<" to the closing ">" (which may be lines later)
+func enclosingTag(lines []string, lineNum, col int) string {
+	line := lines[lineNum]
+	start := strings.LastIndex(line[:col], "<")
+	if start < 0 {
+		start = 0
+	}
+	var b strings.Builder
+	b.WriteString(line[start:])
+	for i := lineNum; ; i++ {
+		text := b.String()
+		if end := strings.Index(text[col-start:], ">"); end >= 0 {
+			return text[:col-start+end+1]
+		}
+		if i+1 >= len(lines) {
+			return text
+		}
+		b.WriteString(" ")
+		b.WriteString(lines[i+1])
+	}
+}
+
+// classifyHtmxTrigger fills the polling and load fields from dep.Trigger, which can list
+// several comma-separated triggers: "load, every 5s [isVisible()]", "every 1m"
+func classifyHtmxTrigger(dep *HtmxDependency) {
+	for _, trigger := range strings.Split(dep.Trigger, ",") {
+		fields := strings.Fields(trigger)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "every":
+			dep.Polling = true
+			if len(fields) > 1 && dep.PollInterval == "" {
+				dep.PollInterval = fields[1]
+			}
+		case "load":
+			dep.LoadOnInit = true
+		}
+	}
+}
+
+// resolveHtmxFragments matches each HTMX request URL's last path segment against the
+// analyzed templates. An exact template name wins, then a name whose last path element
+// matches ("partials/user-list" for /users/user-list), then the defining file's name
+// without its extension. Extensions and query strings on the URL are ignored.
+func (a *TemplateAnalyzer) resolveHtmxFragments() {
+	names := make([]string, 0, len(a.templates))
+	for name := range a.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := []func(def *TmplDef) string{
+		func(def *TmplDef) string { return stripExt(def.Name) },
+		func(def *TmplDef) string { return stripExt(path.Base(def.Name)) },
+		func(def *TmplDef) string { return stripExt(filepath.Base(def.FilePath)) },
+	}
+
+	for _, dep := range a.htmxInfo.Dependencies {
+		segment := htmxURLSegment(dep.URL)
+		// A segment built from an action ({{.ID}}) can't be matched statically
+		if segment == "" || strings.Contains(segment, a.leftDelim) || strings.Contains(segment, a.rightDelim) {
+			continue
+		}
+		for _, key := range keys {
+			for _, name := range names {
+				if key(a.templates[name]) == segment {
+					dep.Resolved = true
+					dep.FragmentName = name
+					break
+				}
+			}
+			if dep.Resolved {
+				break
+			}
+		}
+	}
+}
+
+// htmxURLSegment returns the last path segment of an HTMX URL, without its query
+// string or extension
+func htmxURLSegment(rawURL string) string {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	rawURL = strings.TrimRight(rawURL, "/")
+	return stripExt(rawURL[strings.LastIndex(rawURL, "/")+1:])
+}
+
+// stripExt drops a file extension, if any
+func stripExt(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+var (
+	// alpineAttrRe matches an Alpine directive attribute and its quoted value: x-* names,
+	// the @event shorthand for x-on, and the :attr shorthand for x-bind
+	alpineAttrRe    = regexp.MustCompile(`(?:^|[\s"'])((?:x-[a-z]+(?:[:.][\w.:-]*)?)|(?:@[a-zA-Z][\w.:-]*)|(?::[a-zA-Z][\w.-]*))\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	alpineVersionRe = regexp.MustCompile(`alpinejs@([0-9.]+)`)
+)
+
+// detectAlpine scans HTML content for Alpine.js directives, the way detectHtmx does
+// for hx-* attributes, and for an Alpine script include
+func (a *TemplateAnalyzer) detectAlpine(filePath string, content string) {
+	if strings.Contains(content, "alpinejs") {
+		a.alpineInfo.Detected = true
+		if match := alpineVersionRe.FindStringSubmatch(content); len(match) > 1 {
+			a.alpineInfo.Version = match[1]
+		}
+	}
+
+	for lineNum, line := range strings.Split(content, "\n") {
+		for _, m := range alpineAttrRe.FindAllStringSubmatch(line, -1) {
+			value := m[2]
+			if value == "" {
+				value = m[3]
+			}
+			ctx := strings.TrimSpace(line)
+			if len(ctx) > 100 {
+				ctx = ctx[:97] + "..."
+			}
+			a.alpineInfo.Directives = append(a.alpineInfo.Directives, &AlpineDirective{
+				Directive: m[1],
+				Value:     value,
+				FilePath:  filePath,
+				Line:      lineNum + 1,
+				Context:   ctx,
+			})
+			a.alpineInfo.Detected = true
+		}
+	}
+}
+
+var (
+	assetAttrRe = regexp.MustCompile(`(?i)\b(src|href|srcset|poster)\s*=\s*["']([^"']+)["']`)
+	tagOpenRe   = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9-]*)[^<>]*$`)
+)
+
+// detectExternalAssets scans HTML content line by line, like detectHtmx, for asset
+// attributes that point at remote URLs (http://, https://, or protocol-relative //).
+// Links on <a> elements are navigation rather than assets and are skipped.
+func (a *TemplateAnalyzer) detectExternalAssets(filePath string, content string) {
+	for lineNum, line := range strings.Split(content, "\n") {
+		for _, m := range assetAttrRe.FindAllStringSubmatchIndex(line, -1) {
+			attr := strings.ToLower(line[m[2]:m[3]])
+			value := line[m[4]:m[5]]
+
+			tag := ""
+			if tm := tagOpenRe.FindStringSubmatch(line[:m[0]]); len(tm) > 1 {
+				tag = strings.ToLower(tm[1])
+			}
+			if tag == "a" {
+				continue
+			}
+
+			// srcset holds a comma-separated list of "url descriptor" candidates
+			urls := []string{value}
+			if attr == "srcset" {
+				urls = nil
+				for _, candidate := range strings.Split(value, ",") {
+					if fields := strings.Fields(candidate); len(fields) > 0 {
+						urls = append(urls, fields[0])
+					}
+				}
+			}
+
+			for _, u := range urls {
+				lower := strings.ToLower(u)
+				if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(u, "//") {
+					continue
+				}
+				ctx := strings.TrimSpace(line)
+				if len(ctx) > 100 {
+					ctx = ctx[:97] + "..."
+				}
+				a.externalRefs = append(a.externalRefs, &ExternalAsset{
+					Tag:      tag,
+					Attr:     attr,
+					URL:      u,
+					FilePath: filePath,
+					Line:     lineNum + 1,
+					Context:  ctx,
+				})
+			}
+		}
+	}
+}
+
+var (
+	scriptBlockRe = regexp.MustCompile(`(?is)<script([^>]*)>(.*?)</script>`)
+	styleBlockRe  = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+	scriptTypeRe  = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// detectEscapingIssues flags template actions inside <script> and <style> blocks that
+// aren't passed through safeJS/safeCSS. html/template escapes those contexts as JS and
+// CSS, which often surprises authors (quoted strings in JS, ZgotmplZ in CSS).
+func (a *TemplateAnalyzer) detectEscapingIssues(filePath string, content string) {
+	for _, m := range scriptBlockRe.FindAllStringSubmatchIndex(content, -1) {
+		attrs := content[m[2]:m[3]]
+		if typeMatch := scriptTypeRe.FindStringSubmatch(attrs); len(typeMatch) > 1 && !isJSScriptType(typeMatch[1]) {
+			continue // Non-JS script types (e.g., text/template) aren't escaped as JS
+		}
+		a.checkBlockActions(filePath, content, m[4], m[5], "script-escaping", "safeJS",
+			"is escaped as a JavaScript value inside <script> (strings become quoted literals); wrap it with safeJS if it is trusted code")
+	}
+	for _, m := range styleBlockRe.FindAllStringSubmatchIndex(content, -1) {
+		a.checkBlockActions(filePath, content, m[2], m[3], "style-escaping", "safeCSS",
+			"is sanitized as CSS inside <style> (unsafe values render as ZgotmplZ); wrap it with safeCSS if it is trusted")
+	}
+}
+
+// eventAttrRe matches the start of an inline event handler attribute value:
+// onclick="..." or hx-on:click='...'
+var eventAttrRe = regexp.MustCompile(`(?i)[\s"']((?:hx-on[:-][\w:.-]*)|(?:on[a-z]+))\s*=\s*(["'])`)
+
+// detectEventHandlers flags output actions inside inline event handler attributes.
+// Their values run as JavaScript, so interpolated data is an XSS risk: html/template
+// escapes it as a JS value, but safeJS/safeAttr/safeHTML bypass that entirely.
+func (a *TemplateAnalyzer) detectEventHandlers(filePath string, content string) {
+	for _, m := range eventAttrRe.FindAllStringSubmatchIndex(content, -1) {
+		attr := content[m[2]:m[3]]
+		quote := content[m[4]]
+
+		// Find the closing quote, skipping over actions (which may contain quotes)
+		start, end := m[5], len(content)
+		for i := start; i < len(content); i++ {
+			if strings.HasPrefix(content[i:], a.leftDelim) {
+				if n := strings.Index(content[i:], a.rightDelim); n >= 0 {
+					i += n + len(a.rightDelim) - 1
+					continue
+				}
+			}
+			if content[i] == quote {
+				end = i
+				break
+			}
+		}
+
+		value := content[start:end]
+		for _, am := range a.actionRe.FindAllStringSubmatchIndex(value, -1) {
+			action := value[am[2]:am[3]]
+			if !isOutputAction(action) {
+				continue
+			}
+			message := fmt.Sprintf("{{%s}} is interpolated into the %s handler, which runs as JavaScript; pass data through a data-* attribute instead if it can contain user input", action, attr)
+			for _, bypass := range []string{"safeJS", "safeAttr", "safeHTML"} {
+				if strings.Contains(action, bypass) {
+					message = fmt.Sprintf("{{%s}} uses %s inside the %s handler, which disables escaping; any user input in it is an XSS vector", action, bypass, attr)
+					break
+				}
+			}
+			ctx := content[m[2]:end]
+			if end < len(content) {
+				ctx += string(quote)
+			}
+			if len(ctx) > 100 {
+				ctx = ctx[:97] + "..."
+			}
+			a.warnings = append(a.warnings, &TemplateWarning{
+				Type:     "event-handler",
+				Message:  message,
+				FilePath: filePath,
+				Line:     strings.Count(content[:start+am[0]], "\n") + 1,
+				Context:  ctx,
+			})
+		}
+	}
+}
+
+// checkBlockActions records a warning for each output action in content[start:end]
+// that doesn't use the given safe helper
+func (a *TemplateAnalyzer) checkBlockActions(filePath, content string, start, end int, warnType, safeFunc, message string) {
+	block := content[start:end]
+	for _, m := range a.actionRe.FindAllStringSubmatchIndex(block, -1) {
+		action := block[m[2]:m[3]]
+		if !isOutputAction(action) || strings.Contains(action, safeFunc) {
+			continue
+		}
+		offset := start + m[0]
+		a.warnings = append(a.warnings, &TemplateWarning{
+			Type:     warnType,
+			Message:  fmt.Sprintf("{{%s}} %s", action, message),
+			FilePath: filePath,
+			Line:     strings.Count(content[:offset], "\n") + 1,
+			Context:  block[m[0]:m[1]],
+		})
+	}
+}
+
+// isOutputAction reports whether an action's text produces output, as opposed to
+// control flow ({{if}}, {{end}}, ...), comments, or variable declarations
+func isOutputAction(action string) bool {
+	if action == "" || strings.HasPrefix(action, "/*") {
+		return false
+	}
+	keyword := strings.Fields(action)[0]
+	switch keyword {
+	case "if", "else", "end", "range", "with", "define", "block", "template", "break", "continue":
+		return false
+	}
+	// Declarations and assignments like {{$x := .Foo}} don't print anything
+	if strings.HasPrefix(keyword, "$") && (strings.Contains(action, ":=") || strings.Contains(action, " = ")) {
+		return false
+	}
+	return true
+}
+
+// isJSScriptType reports whether a <script type> is treated as JavaScript by html/template
+func isJSScriptType(mimeType string) bool {
+	switch strings.ToLower(mimeType) {
+	case "module", "text/javascript", "application/javascript", "application/ecmascript",
+		"text/ecmascript", "application/json", "application/ld+json", "text/babel", "text/jsx":
+		return true
+	}
+	return false
+}
+
+// dumpParseNode converts a parse tree node and its children into a TreeNode
+func dumpParseNode(tree *parse.Tree, node parse.Node) *TreeNode {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return nil
+	}
+
+	location, _ := tree.ErrorContext(node)
+	out := &TreeNode{
+		Type:     strings.TrimPrefix(fmt.Sprintf("%T", node), "*parse."),
+		Pos:      int(node.Position()),
+		Location: location,
+	}
+
+	var children []parse.Node
+	switch n := node.(type) {
+	case *parse.ListNode:
+		children = n.Nodes
+	case *parse.ActionNode:
+		children = []parse.Node{n.Pipe}
+	case *parse.IfNode:
+		children = []parse.Node{n.Pipe, n.List, n.ElseList}
+	case *parse.RangeNode:
+		children = []parse.Node{n.Pipe, n.List, n.ElseList}
+	case *parse.WithNode:
+		children = []parse.Node{n.Pipe, n.List, n.ElseList}
+	case *parse.TemplateNode:
+		out.Text = n.Name
+		children = []parse.Node{n.Pipe}
+	case *parse.PipeNode:
+		for _, v := range n.Decl {
+			children = append(children, v)
+		}
+		for _, c := range n.Cmds {
+			children = append(children, c)
+		}
+	case *parse.CommandNode:
+		children = n.Args
+	case *parse.ChainNode:
+		out.Text = n.String()
+		children = []parse.Node{n.Node}
+	default:
+		out.Text = node.String()
+	}
+
+	if len(out.Text) > 80 {
+		out.Text = out.Text[:77] + "..."
+	}
+	for _, child := range children {
+		if c := dumpParseNode(tree, child); c != nil {
+			out.Children = append(out.Children, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+// TestWithSubjectType checks that a with subject whose fields are read is typed as an
+// object, while one used only as a value keeps its leaf type
+func TestWithSubjectType(t *testing.T) {
+	dir := t.TempDir()
+	entry := writeTestFile(t, dir, "page.html",
+		`{{with .User}}{{.Email}}{{end}}{{with .Title}}<h1>{{.}}</h1>{{end}}`)
+
+	graph, err := NewTemplateAnalyzer(dir).Analyze(entry, []string{entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]string)
+	for _, v := range graph.Variables {
+		types[v.Path] = v.Type
+	}
+
+	want := map[string]string{
+		"User":       "object",
+		"User.Email": "string",
+		"Title":      "string",
+	}
+	for path, typ := range want {
+		if types[path] != typ {
+			t.Errorf("%s type = %q, want %q (all: %v)", path, types[path], typ, types)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressHandler gzips (or deflates) text responses for clients that accept it, which
+// matters when the dev server is reached over a slow tunnel. The live-reload stream,
+// range requests, and already-encoded responses (e.g., from the API proxy) pass through
+// untouched, as do formats that are compressed already (images, fonts, archives).
+func compressHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.URL.Path == "/__reload" || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, preferring
+// gzip when both are acceptable. It returns "" when neither is.
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// isCompressibleType reports whether a Content-Type is worth compressing: text,
+// scripts, data formats, and SVG. Binary media is already compressed.
+func isCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") || strings.HasSuffix(mediaType, "+json") {
+		return true
+	}
+	switch mediaType {
+	case "application/javascript", "application/json", "application/xml", "application/wasm",
+		"application/manifest+json", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter decides on the first WriteHeader/Write whether to compress, based on
+// the response headers the handler set by then
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	decided     bool
+	wroteHeader bool
+	compressor  io.WriteCloser // nil when the response passes through
+}
+
+func (cw *compressWriter) decide(status int, firstChunk []byte) {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(firstChunk) > 0 {
+		h.Set("Content-Type", http.DetectContentType(firstChunk))
+	}
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" || !isCompressibleType(h.Get("Content-Type")) {
+		return
+	}
+
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", cw.encoding)
+	h.Add("Vary", "Accept-Encoding")
+	if cw.encoding == "gzip" {
+		cw.compressor = gzip.NewWriter(cw.ResponseWriter)
+	} else {
+		cw.compressor, _ = flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+	}
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.decide(status, nil)
+	cw.wroteHeader = true
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.decide(http.StatusOK, b)
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush pushes buffered compressed bytes to the client, for handlers that stream
+func (cw *compressWriter) Flush() {
+	if f, ok := cw.compressor.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the compressed stream; responses that passed through need nothing
+func (cw *compressWriter) Close() error {
+	if cw.compressor == nil {
+		return nil
+	}
+	return cw.compressor.Close()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// dataViewMaxDepth stops runaway nesting in the data view
+const dataViewMaxDepth = 32
+
+// dataViewURL returns the /__data link for a page, including the mount prefix.
+func (s *DevServer) dataViewURL(urlPath string) string {
+	return s.withPrefix("/__data") + "?path=" + url.QueryEscape(urlPath)
+}
+
+// handleDataView renders the merged render data for a page as a collapsible HTML tree.
+// Keys are shown with the template path that reaches them (e.g., .Site.Pages[0].Title),
+// so authors can copy them straight into a template. The page comes from ?path= or the
+// URL itself (/__data/apps); ?format=json or an Accept header preferring JSON returns
+// the same data as pretty-printed JSON instead.
+func (s *DevServer) handleDataView(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Query().Get("path")
+	if urlPath == "" {
+		urlPath = strings.TrimPrefix(r.URL.Path, "/__data")
+	}
+	if urlPath == "" {
+		urlPath = "/"
+	}
+
+	if r.URL.Query().Get("format") == "json" || prefersJSON(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(s.pageRenderData(urlPath))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Data · `)
+	b.WriteString(html.EscapeString(urlPath))
+	b.WriteString(`</title>
+<style>
+body { font: 13px/1.5 ui-monospace, SFMono-Regular, Menlo, monospace; margin: 24px; color: #1f2328; }
+h1 { font-size: 15px; }
+ul { list-style: none; margin: 0; padding-left: 18px; }
+summary { cursor: pointer; }
+.key { color: #0550ae; }
+.type { color: #6e7781; margin-left: 6px; }
+.string { color: #0a3069; }
+.number { color: #953800; }
+.bool, .null { color: #8250df; }
+</style></head><body>
+<h1>Render data for <a href="`)
+	b.WriteString(html.EscapeString(s.withPrefix(urlPath)))
+	b.WriteString(`">`)
+	b.WriteString(html.EscapeString(urlPath))
+	b.WriteString("</a></h1>\n")
+	fmt.Fprintf(&b, "<p><a href=\"%s\">View as JSON</a></p>\n", html.EscapeString(s.dataViewURL(urlPath)+"&format=json"))
+	writeDataTree(&b, "", "", reflect.ValueOf(s.pageRenderData(urlPath)), 0)
+	b.WriteString("</body></html>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(b.String()))
+}
+
+// writeDataTree writes v as a nested list. Objects and lists are <details> blocks,
+// open for the first two levels; path is the template expression that reaches v.
+func writeDataTree(b *strings.Builder, label, path string, v reflect.Value, depth int) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+
+	writeLabel := func() {
+		if label != "" {
+			fmt.Fprintf(b, `<span class="key" title="%s">%s</span>: `, html.EscapeString(path), html.EscapeString(label))
+		}
+	}
+	openContainer := func(summary string) {
+		open := ""
+		if depth < 2 {
+			open = " open"
+		}
+		fmt.Fprintf(b, "<details%s><summary>", open)
+		writeLabel()
+		fmt.Fprintf(b, `<span class="type">%s</span></summary><ul>`, summary)
+	}
+
+	if depth > dataViewMaxDepth {
+		writeLabel()
+		b.WriteString(`<span class="type">…</span>`)
+		return
+	}
+	if !v.IsValid() || ((v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer ||
+		v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil()) {
+		writeLabel()
+		b.WriteString(`<span class="null">null</span>`)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		openContainer(t.Name())
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			name := t.Field(i).Name
+			b.WriteString("<li>")
+			writeDataTree(b, name, path+"."+name, v.Field(i), depth+1)
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul></details>")
+
+	case reflect.Map:
+		openContainer(fmt.Sprintf("object · %d keys", v.Len()))
+		for _, k := range sortedMapKeys(v) {
+			key := fmt.Sprint(k.Interface())
+			b.WriteString("<li>")
+			writeDataTree(b, key, path+"."+key, v.MapIndex(k), depth+1)
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul></details>")
+
+	case reflect.Slice, reflect.Array:
+		openContainer(fmt.Sprintf("array · %d items", v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			b.WriteString("<li>")
+			writeDataTree(b, fmt.Sprintf("[%d]", i), fmt.Sprintf("%s[%d]", path, i), v.Index(i), depth+1)
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul></details>")
+
+	case reflect.String:
+		writeLabel()
+		fmt.Fprintf(b, `<span class="string">%s</span>`, html.EscapeString(fmt.Sprintf("%q", v.String())))
+
+	case reflect.Bool:
+		writeLabel()
+		fmt.Fprintf(b, `<span class="bool">%t</span>`, v.Bool())
+
+	default:
+		writeLabel()
+		fmt.Fprintf(b, `<span class="number">%s</span>`, html.EscapeString(fmt.Sprint(v.Interface())))
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExportManifest is the machine-readable inventory written alongside an export.
+type ExportManifest struct {
+	Generated string          `json:"generated"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry describes a single exported file.
+type ManifestEntry struct {
+	Path     string `json:"path"`               // Output path relative to the export directory
+	URL      string `json:"url"`                // URL path the file was rendered from
+	Source   string `json:"source"`             // Template file that produced it
+	DataFile string `json:"dataFile,omitempty"` // Data file used, if any
+	Hash     string `json:"hash"`               // sha256 of the written content
+}
+
+// exportTarget is a page to render during export.
+type exportTarget struct {
+	URLPath  string
+	Source   string
+	DataFile string
+}
+
+// runExport renders every navigable page of the site to outDir as static HTML.
+// When since is set ("last" reads the previous manifest's timestamp), pages whose
+// template, reachable partials/layouts, and data are all older are left as they are.
+func runExport(configFile, configJSON, outDir string, emitManifest bool, since string) error {
+	cfg, err := loadServeConfig(configFile, configJSON)
+	if err != nil {
+		return err
+	}
+	if cfg.IndexFile == "" {
+		cfg.IndexFile = autoDetectIndex(cfg.PagesDir, cfg.indexNames())
+	}
+	cfg.Prefix = normalizePrefix(cfg.Prefix)
+
+	srv, err := newDevServer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+	srv.exporting = true
+
+	var sinceTime time.Time
+	if since != "" {
+		sinceTime, err = parseExportSince(since, outDir)
+		if err != nil {
+			return err
+		}
+		// The manifest carries the timestamp the next incremental run starts from
+		emitManifest = true
+	}
+
+	manifest := ExportManifest{
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Files:     []ManifestEntry{},
+	}
+
+	targets := srv.exportTargets()
+	var deps *exportDeps
+	if !sinceTime.IsZero() {
+		deps = srv.newExportDeps(targets)
+	}
+
+	skipped := 0
+	for _, target := range targets {
+		relPath := exportPathFor(target.URLPath)
+		outPath := filepath.Join(outDir, relPath)
+
+		var content []byte
+		if deps != nil && !deps.changedSince(target, sinceTime) {
+			// Unchanged since the last export — keep the existing output if it's there
+			if existing, err := os.ReadFile(outPath); err == nil {
+				content = existing
+				skipped++
+			}
+		}
+
+		if content == nil {
+			content, err = srv.renderForExport(target.URLPath)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(outPath), err)
+			}
+			if err := os.WriteFile(outPath, content, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", outPath, err)
+			}
+			log.Printf("📝 %s → %s", target.URLPath, outPath)
+		}
+
+		sum := sha256.Sum256(content)
+		manifest.Files = append(manifest.Files, ManifestEntry{
+			Path:     filepath.ToSlash(relPath),
+			URL:      target.URLPath,
+			Source:   target.Source,
+			DataFile: target.DataFile,
+			Hash:     "sha256:" + hex.EncodeToString(sum[:]),
+		})
+	}
+
+	if emitManifest {
+		raw, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		manifestPath := filepath.Join(outDir, "manifest.json")
+		if err := os.WriteFile(manifestPath, raw, 0644); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		log.Printf("📋 Wrote manifest with %d files to %s", len(manifest.Files), manifestPath)
+	}
+
+	if skipped > 0 {
+		log.Printf("✅ Exported %d pages to %s (%d unchanged)", len(manifest.Files)-skipped, outDir, skipped)
+	} else {
+		log.Printf("✅ Exported %d pages to %s", len(manifest.Files), outDir)
+	}
+	return nil
+}
+
+// parseExportSince resolves the -since value: an RFC 3339 timestamp, or "last" for
+// the generated time recorded in outDir/manifest.json by the previous export.
+func parseExportSince(since, outDir string) (time.Time, error) {
+	if since == "last" {
+		raw, err := os.ReadFile(filepath.Join(outDir, "manifest.json"))
+		if err != nil {
+			// No previous export — everything is new
+			return time.Time{}, nil
+		}
+		var prev ExportManifest
+		if err := json.Unmarshal(raw, &prev); err != nil {
+			return time.Time{}, fmt.Errorf("invalid manifest in %s: %w", outDir, err)
+		}
+		since = prev.Generated
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -since timestamp %q (want RFC 3339 or \"last\"): %w", since, err)
+	}
+	return t, nil
+}
+
+// ── Incremental export ──────────────────────────────────────────────────────
+
+// exportDeps resolves which files each exported page depends on, using the
+// analyzer's define/invoke graph over the shared templates and page files.
+type exportDeps struct {
+	analyzer *TemplateAnalyzer
+	shared   []string            // Layouts and partials available to every page
+	roots    []string            // Shared files every page renders through (the layouts)
+	data     []string            // Data files every page reads (context mode's linked data)
+	definers map[string][]string // Template name → shared files defining it
+}
+
+// newExportDeps analyzes the shared templates and every target's page file.
+func (s *DevServer) newExportDeps(targets []exportTarget) *exportDeps {
+	d := &exportDeps{
+		analyzer: NewTemplateAnalyzer(""),
+		definers: make(map[string][]string),
+	}
+	d.analyzer.SetDelims(s.cfg.delims())
+
+	if s.contextMode {
+		for _, file := range s.sharedFiles {
+			if fileExistsServe(file) {
+				d.shared = append(d.shared, file)
+			}
+		}
+		d.roots = []string{s.cfg.EntryFile}
+		if s.cfg.DataFile != "" {
+			d.data = []string{s.cfg.DataFile}
+		}
+	} else {
+		// Any layout may end up wrapping the page, so a layout change re-renders everything
+		layouts, _ := filepath.Glob(filepath.Join(s.cfg.LayoutsDir, "*.html"))
+		partials, _ := filepath.Glob(filepath.Join(s.cfg.PartialsDir, "*.html"))
+		d.shared = append(layouts, partials...)
+		d.roots = layouts
+	}
+
+	for _, file := range d.shared {
+		if err := d.analyzer.analyzeFile(file); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+		d.definers[filepath.Base(file)] = append(d.definers[filepath.Base(file)], file)
+		for _, name := range d.analyzer.fileDefines[file] {
+			d.definers[name] = append(d.definers[name], file)
+		}
+	}
+	for _, target := range targets {
+		if err := d.analyzer.analyzeFile(target.Source); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+	return d
+}
+
+// filesFor returns every template file reachable from the page and the layouts.
+// Other pages are never included, even though they define the same slot names.
+func (d *exportDeps) filesFor(target exportTarget) []string {
+	seen := make(map[string]bool)
+	queue := append([]string{target.Source}, d.roots...)
+	var files []string
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+		for _, name := range d.analyzer.fileInvokes[file] {
+			queue = append(queue, d.definers[name]...)
+		}
+	}
+	return files
+}
+
+// changedSince reports whether any of the page's templates or data files were
+// modified after since. Missing files count as changed.
+func (d *exportDeps) changedSince(target exportTarget, since time.Time) bool {
+	files := append(d.filesFor(target), d.data...)
+	if target.DataFile != "" {
+		files = append(files, target.DataFile)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.ModTime().After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportTargets lists the pages to export along with their source template and data file.
+func (s *DevServer) exportTargets() []exportTarget {
+	var targets []exportTarget
+
+	if s.contextMode {
+		s.contextPageMu.RLock()
+		defer s.contextPageMu.RUnlock()
+		for _, p := range s.contextPages {
+			dataFile := p.DataFile
+			if dataFile == "" {
+				dataFile = s.cfg.DataFile
+			}
+			targets = append(targets, exportTarget{URLPath: p.URLPath, Source: p.FilePath, DataFile: dataFile})
+		}
+		// Without discovered pages, "/" renders the shared context files alone
+		if len(targets) == 0 {
+			targets = append(targets, exportTarget{URLPath: "/", Source: s.cfg.EntryFile, DataFile: s.cfg.DataFile})
+		}
+		return targets
+	}
+
+	s.mu.RLock()
+	root := s.root
+	s.mu.RUnlock()
+
+	var walk func(p *Page)
+	walk = func(p *Page) {
+		// Dynamic pages need a slug, so they can't be exported without one
+		if p.File != "" && !p.Dynamic {
+			dataFile := strings.TrimSuffix(p.File, filepath.Ext(p.File)) + ".json"
+			if !fileExistsServe(dataFile) {
+				dataFile = ""
+			}
+			targets = append(targets, exportTarget{URLPath: p.Path, Source: p.File, DataFile: dataFile})
+		}
+		for _, child := range p.Children {
+			walk(child)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+	return targets
+}
+
+// renderForExport renders a page with the same render path the server uses, minus the
+// request handling around it: no trailing-slash redirect (each page is written as
+// dir/index.html, which suits every trailingSlash mode), no query overrides, and no
+// live reload.
+func (s *DevServer) renderForExport(urlPath string) ([]byte, error) {
+	var output string
+	var found bool
+	var err error
+	if s.contextMode {
+		output, found, err = s.renderContextPage(urlPath, s.newResponseNonce(), nil)
+	} else {
+		output, found, err = s.renderConventionPage(urlPath, s.newResponseNonce(), nil)
+	}
+	if !found {
+		return nil, fmt.Errorf("failed to render %s: no page matches", urlPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", urlPath, err)
+	}
+	return []byte(output), nil
+}
+
+// exportPathFor maps a URL path to its output file (e.g., "/apps" → "apps/index.html").
+func exportPathFor(urlPath string) string {
+	clean := strings.Trim(urlPath, "/")
+	if clean == "" {
+		return "index.html"
+	}
+	return filepath.Join(filepath.FromSlash(clean), "index.html")
+}
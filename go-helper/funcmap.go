@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// assetResolver supplies the helpers that depend on where files live and how the site
+// is mounted. The dev server answers from its config (prefix, iconsDir, static root);
+// the renderer links paths as given and reads files from the workspace.
+type assetResolver interface {
+	withPrefix(urlPath string) string
+	svgIcon(name string, attrs ...any) (template.HTML, error)
+	b64img(path string) (template.URL, error)
+}
+
+// commonFuncMap returns the helpers shared by every mode: render and serve use it with
+// their own assets, and the analyzer stubs the same names so parsing accepts whatever
+// the runtime supports.
+func commonFuncMap(assets assetResolver) template.FuncMap {
+	return template.FuncMap{
+		// Asset helpers
+		// url prefixes a site-absolute path with the mount prefix: {{url "/apps"}}
+		"url": assets.withPrefix,
+		// svgIcon inlines an SVG icon: {{svgIcon "check" "class" "icon" "size" 16}}
+		"svgIcon": assets.svgIcon,
+		// b64img embeds an image as a data: URL: <img src="{{b64img "logo.png"}}">
+		"b64img": assets.b64img,
+
+		// Comparison (flexible for JSON float64 vs int)
+		"eq": flexibleEq,
+		"ne": flexibleNe,
+		"lt": flexibleLt,
+		"le": flexibleLe,
+		"gt": flexibleGt,
+		"ge": flexibleGe,
+
+		// Integer math
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": func(a, b int) int {
+			if b == 0 {
+				return 0
+			}
+			return a / b
+		},
+		"mod": func(a, b int) int {
+			if b == 0 {
+				return 0
+			}
+			return a % b
+		},
+
+		// Float math accepts ints or JSON float64s, so percentages need no casting
+		"addf": func(a, b interface{}) (float64, error) {
+			x, y, err := floatOperands(a, b)
+			return x + y, err
+		},
+		"subf": func(a, b interface{}) (float64, error) {
+			x, y, err := floatOperands(a, b)
+			return x - y, err
+		},
+		"mulf": func(a, b interface{}) (float64, error) {
+			x, y, err := floatOperands(a, b)
+			return x * y, err
+		},
+		"divf": func(a, b interface{}) (float64, error) {
+			x, y, err := floatOperands(a, b)
+			if err != nil || y == 0 {
+				return 0, err
+			}
+			return x / y, nil
+		},
+
+		// String manipulation
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"title":     titleCase,
+		"trim":      strings.TrimSpace,
+		"contains":  strings.Contains,
+		"replace":   strings.ReplaceAll,
+		"split":     strings.Split,
+		"join":      strings.Join,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+
+		"containsAny": containsAny,
+		"matchesGlob": matchesGlob,
+
+		// Length helpers count runes, so multibyte text is never cut mid-character
+		"truncate": truncateRunes,
+		"wordwrap": wordWrap,
+
+		// Count labels: {{ .Count }} {{ pluralize .Count "item" }}
+		"pluralize":   pluralize,
+		"singularize": singularize,
+
+		// Case conversion
+		"toTitle":    serveTitleCase,
+		"camelCase":  camelCase,
+		"pascalCase": pascalCase,
+		"snakeCase":  snakeCase,
+		"kebabCase":  kebabCase,
+
+		// Conditional helpers
+		// default: only nil and "" count as missing, so 0 and false are kept
+		"default": defaultValue,
+		"ternary": func(cond bool, trueVal, falseVal interface{}) interface{} {
+			if cond {
+				return trueVal
+			}
+			return falseVal
+		},
+
+		// Navigation helpers
+		"isActive": func(current, target string) bool {
+			current = strings.TrimSuffix(current, "/")
+			target = strings.TrimSuffix(target, "/")
+			if current == "" {
+				current = "/"
+			}
+			if target == "" {
+				target = "/"
+			}
+			return current == target
+		},
+		"isActivePrefix": func(current, target string) bool {
+			return strings.HasPrefix(current, target)
+		},
+
+		// HTML helpers
+		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
+		"safeAttr": func(s string) template.HTMLAttr { return template.HTMLAttr(s) },
+		"safeURL":  func(s string) template.URL { return template.URL(s) },
+		"safeCSS":  func(s string) template.CSS { return template.CSS(s) },
+		"safeJS":   func(s string) template.JS { return template.JS(s) },
+
+		// JSON helpers
+		"toJSON":  toJSON,
+		"jsonify": toJSON,
+
+		// Markdown helpers
+		"markdown":       markdownHTML,
+		"markdownify":    markdownHTML,
+		"markdownUnsafe": markdownUnsafeHTML,
+
+		// Map helpers
+		"dict": func(values ...any) map[string]any {
+			if len(values)%2 != 0 {
+				return nil
+			}
+			m := make(map[string]any, len(values)/2)
+			for i := 0; i < len(values); i += 2 {
+				if key, ok := values[i].(string); ok {
+					m[key] = values[i+1]
+				}
+			}
+			return m
+		},
+		"keys":   mapKeys,
+		"values": mapValues,
+
+		// URL helpers
+		"querystring": queryString,
+		"urlJoin":     urlJoin,
+
+		// CSV helpers
+		"csvField": csvField,
+
+		// Slice helpers
+		// isLast/isFirst accept (index, slice) to check position
+		"isLast": func(i int, slice interface{}) bool {
+			v := reflect.ValueOf(slice)
+			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				return i == v.Len()-1
+			}
+			return false
+		},
+		"isFirst": func(i int) bool { return i == 0 },
+		"len": func(v interface{}) int {
+			rv := reflect.ValueOf(v)
+			switch rv.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
+				return rv.Len()
+			default:
+				return 0
+			}
+		},
+		"seq":  seqStep,
+		"list": func(values ...any) []any { return values },
+		// slice is the builtin's item[start:end], but out-of-range indices are clamped
+		"slice": func(item interface{}, indices ...int) interface{} {
+			if !reflect.ValueOf(item).IsValid() {
+				return ""
+			}
+			switch len(indices) {
+			case 1:
+				return subslice(item, 0, indices[0])
+			case 2:
+				return subslice(item, indices[0], indices[1])
+			default:
+				return item
+			}
+		},
+		"subslice": subslice,
+		"at":       safeAt,
+
+		// Time helpers
+		"now":            time.Now,
+		"nowUTC":         func() time.Time { return time.Now().UTC() },
+		"year":           func() int { return time.Now().Year() },
+		"formatDate":     formatDate,
+		"formatTime":     formatTime,
+		"formatDateTime": formatDateTime,
+		"rfc3339":        formatRFC3339,
+		"date":           formatTimeLayout,
+		"dateFormat":     formatTimeLayout,
+
+		// Duration helpers
+		"humanizeDuration": humanizeDuration,
+		"formatDuration":   formatDuration,
+	}
+}
+
+// titleCase capitalizes the first letter after whitespace or punctuation, so both
+// "hello world" and "o'neil-smith" come out capitalized per word
+func titleCase(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(prev) || unicode.IsPunct(prev) {
+			prev = r
+			return unicode.ToTitle(r)
+		}
+		prev = r
+		return r
+	}, s)
+}
+
+// truncateRunes cuts s to n runes and appends an ellipsis, trimming any space left
+// before it: {{ .Summary | truncate 80 }}. Strings of n runes or fewer are unchanged.
+func truncateRunes(n int, s string) string {
+	if n < 0 {
+		n = 0
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace) + "…"
+}
+
+// wordWrap breaks each line of s at spaces so lines stay within n runes where the
+// words allow: {{ .Body | wordwrap 72 }}. Only lines longer than n change: a break
+// replaces the spaces it falls on, wrapped lines repeat the line's indentation, and
+// other whitespace is kept. A word longer than n gets a line of its own rather than
+// being split, and existing line breaks are kept.
+func wordWrap(n int, s string) string {
+	if n < 1 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) > n {
+			lines[i] = wrapLine(n, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine wraps a single line for wordWrap
+func wrapLine(n int, line string) string {
+	rest := strings.TrimLeftFunc(line, unicode.IsSpace)
+	indent := line[:len(line)-len(rest)]
+	indentWidth := utf8.RuneCountInString(indent)
+
+	var b strings.Builder
+	b.WriteString(indent)
+	width := indentWidth
+	first := true
+	for rest != "" {
+		word := strings.TrimLeftFunc(rest, unicode.IsSpace)
+		sep := rest[:len(rest)-len(word)]
+		end := strings.IndexFunc(word, unicode.IsSpace)
+		if end < 0 {
+			end = len(word)
+		}
+		word, rest = word[:end], word[end:]
+		if word == "" {
+			b.WriteString(sep) // Trailing whitespace
+			break
+		}
+
+		wordWidth := utf8.RuneCountInString(word)
+		sepWidth := utf8.RuneCountInString(sep)
+		switch {
+		case first:
+			first = false
+		case width+sepWidth+wordWidth > n:
+			b.WriteString("\n" + indent)
+			width = indentWidth
+		default:
+			b.WriteString(sep)
+			width += sepWidth
+		}
+		b.WriteString(word)
+		width += wordWidth
+	}
+	return b.String()
+}
+
+// pluralize returns word for a count of exactly 1 and its naive English plural
+// otherwise: "category" -> "categories", "box" -> "boxes", "item" -> "items".
+// n may be an int or a number from JSON.
+func pluralize(n interface{}, word string) (string, error) {
+	count, ok := toFloat64(n)
+	if !ok {
+		return "", fmt.Errorf("%v is not a number", n)
+	}
+	if count == 1 {
+		return word, nil
+	}
+	return pluralForm(word), nil
+}
+
+// pluralForm applies the -y -> -ies and -s/-x/-z/-ch/-sh -> -es rules, else adds -s
+func pluralForm(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case word == "":
+		return word
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return word[:len(word)-1] + "ies"
+	case hasAnySuffix(lower, "s", "x", "z", "ch", "sh"):
+		return word + "es"
+	default:
+		return word + "s"
+	}
+}
+
+// singularize undoes pluralForm: "categories" -> "category", "boxes" -> "box",
+// "buses" -> "bus", "items" -> "item". Words that don't look plural ("class", "bus")
+// are unchanged. A consonant before "uses" marks an "-us" word, so "statuses" gives
+// "status" while "houses" gives "house"; the rare "fuses" comes out as "fus".
+func singularize(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return word[:len(word)-3] + "y"
+	case hasAnySuffix(lower, "sses", "xes", "zes", "ches", "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "uses") && len(lower) > 4 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-5])):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "s") && !hasAnySuffix(lower, "ss", "us", "is"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// hasAnySuffix reports whether s ends with any of the suffixes
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// toJSON marshals v for embedding in a <script>: var data = {{ .Config | toJSON }}.
+// encoding/json escapes <, >, and & as \u003c and friends, so the result can't close
+// the script element. A value that can't be marshaled renders as an empty object.
+func toJSON(v interface{}) template.JS {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return template.JS("{}")
+	}
+	return template.JS(out)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// FuncInfo describes a template helper function for the funcs command
+type FuncInfo struct {
+	Name        string   `json:"name"`
+	Signature   string   `json:"signature"`
+	Available   []string `json:"available"` // "builtin", "render", "serve"
+	Description string   `json:"description,omitempty"`
+}
+
+// builtinFuncs are provided by text/template itself and exist everywhere
+var builtinFuncs = map[string]string{
+	"and":      "Returns the first empty argument or the last argument",
+	"or":       "Returns the first non-empty argument or the last argument",
+	"not":      "Returns the boolean negation of its argument",
+	"index":    "Indexes into maps, slices, and arrays: index .Map \"key\"",
+	"print":    "fmt.Sprint",
+	"printf":   "fmt.Sprintf",
+	"println":  "fmt.Sprintln",
+	"html":     "Escapes its argument for HTML",
+	"js":       "Escapes its argument for JavaScript",
+	"urlquery": "Escapes its argument for a URL query",
+	"call":     "Calls a function value with the remaining arguments",
+}
+
+// funcDescriptions documents the helpers in the render and serve func maps
+var funcDescriptions = map[string]string{
+	"eq":             "Equality that treats JSON float64 and int literals as the same number",
+	"ne":             "Inverse of eq",
+	"lt":             "Less than, with numeric coercion",
+	"le":             "Less than or equal, with numeric coercion",
+	"gt":             "Greater than, with numeric coercion",
+	"ge":             "Greater than or equal, with numeric coercion",
+	"add":            "Integer addition",
+	"sub":            "Integer subtraction",
+	"mul":            "Integer multiplication",
+	"div":            "Integer division (0 when dividing by zero)",
+	"mod":            "Integer remainder (0 when dividing by zero)",
+	"addf":           "Float addition of ints or numbers from JSON",
+	"subf":           "Float subtraction of ints or numbers from JSON",
+	"mulf":           "Float multiplication of ints or numbers from JSON",
+	"divf":           "Float division (0 when dividing by zero): mulf (divf .Done .Total) 100",
+	"upper":          "Upper-cases a string",
+	"lower":          "Lower-cases a string",
+	"title":          "Capitalizes the first letter of each word",
+	"toTitle":        "Capitalizes words split on spaces, hyphens, and underscores",
+	"camelCase":      "Converts to camelCase",
+	"pascalCase":     "Converts to PascalCase",
+	"snakeCase":      "Converts to snake_case",
+	"kebabCase":      "Converts to kebab-case",
+	"trim":           "Trims surrounding whitespace",
+	"contains":       "Reports whether a string contains a substring",
+	"containsAny":    "Reports whether a string contains any of the substrings: containsAny .Role \"admin\" \"owner\"",
+	"matchesGlob":    "Reports whether a string matches a glob pattern: matchesGlob \"*.png\" .File",
+	"truncate":       "Cuts a string to n characters (runes) and adds an ellipsis: {{ .Summary | truncate 80 }}",
+	"wordwrap":       "Wraps text at spaces so lines stay within n characters: {{ .Body | wordwrap 72 }}",
+	"pluralize":      "Returns the word for a count of 1, else its plural: {{ .N }} {{ pluralize .N \"item\" }}",
+	"singularize":    "Returns the singular of a plural word: singularize \"categories\"",
+	"hasPrefix":      "Reports whether a string starts with a prefix",
+	"hasSuffix":      "Reports whether a string ends with a suffix",
+	"replace":        "Replaces all occurrences of a substring",
+	"split":          "Splits a string on a separator",
+	"join":           "Joins strings with a separator",
+	"isLast":         "Reports whether an index is the last of a slice: isLast $i .Items",
+	"isFirst":        "Reports whether an index is 0",
+	"len":            "Length of a slice, map, or string (0 for other values)",
+	"seq":            "Integers from start to end inclusive, by an optional step: seq 0 100 25",
+	"slice":          "Bounds-safe item[start:end] of a string or list: slice .Items 0 3",
+	"list":           "Builds a list from its arguments: list \"a\" \"b\"",
+	"subslice":       "Bounds-safe item[start:end] for strings and lists",
+	"at":             "Element at an index, or nil when out of range",
+	"keys":           "Sorted keys of a map",
+	"values":         "Values of a map in sorted key order",
+	"dict":           "Builds a map from key/value pairs: dict \"k\" .V",
+	"default":        "Returns the default when the value is nil or an empty string: default \"x\" .V",
+	"ternary":        "Returns the second argument if the condition is true, else the third",
+	"safeHTML":       "Marks a string as trusted HTML",
+	"safeJS":         "Marks a string as trusted JavaScript",
+	"safeCSS":        "Marks a string as trusted CSS",
+	"safeURL":        "Marks a string as a trusted URL",
+	"safeAttr":       "Marks a string as a trusted HTML attribute",
+	"toJSON":         "Marshals a value as JSON for a <script>: var data = {{ .Config | toJSON }}",
+	"jsonify":        "Alias of toJSON",
+	"markdown":       "Renders Markdown to HTML; raw HTML in the source is omitted: {{ .Body | markdown }}",
+	"markdownify":    "Alias of markdown",
+	"markdownUnsafe": "Renders Markdown to HTML and passes raw HTML through (trusted content only)",
+	"isActive":       "Reports whether the current path equals a target path",
+	"isActivePrefix": "Reports whether the current path starts with a target path",
+	"url":            "Prefixes a site-absolute path with the server mount prefix (unchanged in render)",
+	"querystring":    "Encodes a map as a sorted query string (?a=1&b=2); slice values repeat the key",
+	"urlJoin":        "Joins URL path segments with single slashes",
+	"csvField":       "Formats a value as a CSV field, quoting commas, quotes, and line breaks",
+	"svgIcon":        "Inlines an SVG from iconsDir (render: -icons-dir or the workspace) with optional attribute pairs: svgIcon \"check\" \"class\" \"icon\" \"size\" 16",
+	"b64img":         "Embeds a static image (render: from the workspace) as a base64 data: URL",
+	"now":            "The current local time",
+	"nowUTC":         "The current time in UTC",
+	"year":           "The current year, for copyright footers",
+	"formatDate":     "Formats a time, RFC 3339 string, or Unix seconds as 2006-01-02",
+	"formatTime":     "Formats a time as 15:04",
+	"formatDateTime": "Formats a time as 2006-01-02 15:04",
+	"rfc3339":        "Formats a time as RFC 3339",
+	"date":           "Formats a time with a Go layout: {{ now | date \"2006-01-02\" }}",
+	"dateFormat":     "Alias of date: dateFormat \"Jan 2, 2006\" .Published",
+
+	"humanizeDuration": "Formats a number of seconds as a friendly duration: humanizeDuration 8100 → \"2h 15m\"",
+	"formatDuration":   "Formats a number of milliseconds as a friendly duration: formatDuration 1500 → \"1s\"",
+}
+
+// listFuncs gathers every helper from the builtin, render, and serve func maps
+func listFuncs() []FuncInfo {
+	byName := make(map[string]*FuncInfo)
+	add := func(name, source string, fn interface{}) {
+		info, ok := byName[name]
+		if !ok {
+			info = &FuncInfo{Name: name}
+			byName[name] = info
+		}
+		if info.Signature == "" && fn != nil {
+			info.Signature = reflect.TypeOf(fn).String()
+		}
+		info.Available = append(info.Available, source)
+	}
+
+	for name := range builtinFuncs {
+		add(name, "builtin", nil)
+	}
+	for name, fn := range NewTemplateRenderer(".").getTemplateFuncs() {
+		add(name, "render", fn)
+	}
+	for name, fn := range (&DevServer{}).funcMap() {
+		add(name, "serve", fn)
+	}
+
+	funcs := make([]FuncInfo, 0, len(byName))
+	for name, info := range byName {
+		if desc, ok := funcDescriptions[name]; ok {
+			info.Description = desc
+		} else {
+			info.Description = builtinFuncs[name]
+		}
+		funcs = append(funcs, *info)
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+// runListFuncs prints the available template helpers as a table or JSON
+func runListFuncs(asJSON bool) error {
+	funcs := listFuncs()
+
+	if asJSON {
+		output, err := json.MarshalIndent(funcs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tAVAILABLE\tSIGNATURE\tDESCRIPTION")
+	for _, f := range funcs {
+		signature := f.Signature
+		if signature == "" {
+			signature = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Name, strings.Join(f.Available, ","), signature, f.Description)
+	}
+	return tw.Flush()
+}
@@ -2,6 +2,9 @@ module github.com/go-template-viewer/helper
 
 go 1.21
 
-require github.com/fsnotify/fsnotify v1.7.0
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/yuin/goldmark v1.7.8
+)
 
 require golang.org/x/sys v0.4.0 // indirect
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// graphEdge is one {{template}} call between two template names
+type graphEdge struct {
+	From, To string
+	Required bool
+}
+
+// graphNodesAndEdges flattens a TemplateGraph into sorted node names and deduplicated
+// call edges. Called names that no file defines are included as nodes too, so
+// missing templates show up in the diagram.
+func graphNodesAndEdges(graph *TemplateGraph) ([]string, []graphEdge) {
+	required := make(map[string]bool)
+	for _, dep := range graph.Dependencies {
+		required[dep.Name] = dep.Required
+	}
+
+	nodeSet := make(map[string]bool)
+	seenEdge := make(map[[2]string]bool)
+	var edges []graphEdge
+	for name, def := range graph.Templates {
+		nodeSet[name] = true
+		for _, call := range def.Calls {
+			nodeSet[call] = true
+			key := [2]string{name, call}
+			if seenEdge[key] {
+				continue
+			}
+			seenEdge[key] = true
+			edges = append(edges, graphEdge{From: name, To: call, Required: required[call]})
+		}
+	}
+
+	nodes := make([]string, 0, len(nodeSet))
+	for name := range nodeSet {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return nodes, edges
+}
+
+// graphNodeLabel is the template name, plus its file when the name isn't the filename
+func graphNodeLabel(graph *TemplateGraph, name string) string {
+	def, ok := graph.Templates[name]
+	if !ok || def.FilePath == "" || filepath.Base(def.FilePath) == name {
+		return name
+	}
+	return name + "\n" + filepath.Base(def.FilePath)
+}
+
+// formatGraphDOT renders the template call graph as Graphviz DOT. Required calls are
+// solid edges, optional ones dashed; undefined templates are dashed red nodes.
+func formatGraphDOT(graph *TemplateGraph) string {
+	nodes, edges := graphNodesAndEdges(graph)
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph templates {\n  rankdir=LR;\n  node [shape=box, fontname=\"Helvetica\"];\n")
+	for _, name := range nodes {
+		attrs := "label=" + quote(graphNodeLabel(graph, name))
+		if _, defined := graph.Templates[name]; !defined {
+			attrs += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", quote(name), attrs)
+	}
+	for _, e := range edges {
+		style := ""
+		if !e.Required {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", quote(e.From), quote(e.To), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// formatGraphMermaid renders the template call graph as a Mermaid flowchart. Required
+// calls are solid arrows, optional ones dotted; undefined templates are styled red.
+func formatGraphMermaid(graph *TemplateGraph) string {
+	nodes, edges := graphNodesAndEdges(graph)
+	ids := make(map[string]string, len(nodes))
+	for i, name := range nodes {
+		ids[name] = fmt.Sprintf("t%d", i)
+	}
+	label := func(s string) string {
+		s = strings.ReplaceAll(s, `"`, "#quot;")
+		return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	var undefined []string
+	for _, name := range nodes {
+		fmt.Fprintf(&b, "  %s[%s]\n", ids[name], label(graphNodeLabel(graph, name)))
+		if _, defined := graph.Templates[name]; !defined {
+			undefined = append(undefined, ids[name])
+		}
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if !e.Required {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	if len(undefined) > 0 {
+		b.WriteString("  classDef undefined stroke:#d00,stroke-dasharray:4\n")
+		fmt.Fprintf(&b, "  class %s undefined\n", strings.Join(undefined, ","))
+	}
+	return b.String()
+}
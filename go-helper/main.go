@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func main() {
@@ -14,23 +17,82 @@ func main() {
 	inspectEntry := inspectCmd.String("entry", "", "Entry template file")
 	inspectWorkspace := inspectCmd.String("workspace", ".", "Workspace directory")
 	inspectFiles := inspectCmd.String("files", "", "Comma-separated list of template files to include (if empty, auto-discover)")
+	inspectDumpTree := inspectCmd.Bool("dump-tree", false, "Include each template's parse tree (node types and positions) in the output")
+	inspectFormat := inspectCmd.String("format", "json", "Output format: json (full graph), dot (Graphviz), or mermaid (template call graph)")
+	inspectSchema := inspectCmd.Bool("schema", false, "Output a JSON Schema for the data the templates use instead of the graph")
+	inspectPageBlocks := inspectCmd.String("page-blocks", "", "Comma-separated block names that mark a file as a page (default: content)")
+	inspectDelims := inspectCmd.String("delims", "", "Custom action delimiters as \"left right\" (e.g., \"[[ ]]\"; default: {{ }})")
 
 	renderCmd := flag.NewFlagSet("render", flag.ExitOnError)
 	renderEntry := renderCmd.String("entry", "", "Entry template file")
-	renderData := renderCmd.String("data", "", "JSON data file or inline JSON")
+	renderData := renderCmd.String("data", "", "JSON data file, comma-separated files to deep-merge in order (later files win), or inline JSON")
 	renderWorkspace := renderCmd.String("workspace", ".", "Workspace directory")
-	renderTemplate := renderCmd.String("template", "", "Specific template name to render (optional)")
+	renderTemplate := renderCmd.String("template", "", "Specific template name to render: a {{define}} or {{block}} name, or a file name with or without extension (optional)")
+	renderAll := renderCmd.Bool("all", false, "Render every page template in the workspace (files with {{define \"content\"}}) into the -out directory, mirroring the source tree")
+	renderDataDir := renderCmd.String("data-dir", "", "With -all, directory searched for each page's data file (default: <workspace>/.vscode/template-data)")
+	renderContextPath := renderCmd.String("context-path", "", "Data path to pass as dot instead of the whole data, e.g. \"Items[0]\" (for rendering a block or partial on its own)")
 	renderFiles := renderCmd.String("files", "", "Comma-separated list of template files to include (if empty, auto-discover)")
+	renderIncludeMeta := renderCmd.Bool("include-meta", false, "Prepend an HTML comment listing the entry, included files, and data used")
+	renderEscapeMode := renderCmd.String("escape-mode", "html", "Escaping: \"html\" (contextual, default) or \"text\" (none — only for trusted data, output is not XSS-safe)")
+	renderText := renderCmd.Bool("text", false, "Render with text/template for non-HTML output; shorthand for -escape-mode text")
+	renderRawFiles := renderCmd.String("raw-files", "", "Comma-separated entry templates to render without HTML escaping (trusted data only)")
+	renderStats := renderCmd.Bool("stats", false, "Print render statistics (bytes, duration, templates, variables) as JSON to stderr")
+	renderOut := renderCmd.String("out", "", "Write the rendered output to this file (creating parent directories) instead of stdout")
+	renderStrict := renderCmd.Bool("strict", false, "Fail on missing data keys (missingkey=error) instead of rendering <no value>")
+	renderTemplateRoot := renderCmd.String("template-root", "", "Name templates by their path relative to this directory (e.g., \"a/index.html\") instead of their basename, so same-named files don't collide")
+	renderNames := renderCmd.String("names", "base", "Template naming: \"base\" (file basename) or \"relative\" (workspace-relative path, also without extension, e.g. {{template \"partials/card\" .}})")
+	renderIconsDir := renderCmd.String("icons-dir", "", "Directory svgIcon reads icons from, like serve's iconsDir (default: the workspace)")
+	renderDelims := renderCmd.String("delims", "", "Custom action delimiters as \"left right\" (e.g., \"[[ ]]\"; default: {{ }})")
+	renderAllowMissing := renderCmd.Bool("allow-missing-includes", false, "Warn about unreadable -files entries instead of failing the render")
+	var renderSet stringList
+	renderCmd.Var(&renderSet, "set", "Override a data value as key=value; dotted keys nest and numbers/booleans are typed (repeatable)")
+	renderRepeat := renderCmd.String("repeat-data", "", "Comma-separated path=count pairs that fill arrays with varied copies of their first item (e.g., Items=5)")
+	renderWatch := renderCmd.Bool("watch", false, "Re-render whenever the entry, its -files, or the data file changes (errors are printed and watching continues)")
+	renderPrettyErrors := renderCmd.Bool("pretty-errors", true, "Colorize errors with source context (disabled automatically when stderr is not a terminal)")
+
+	validateCmd := flag.NewFlagSet("validate", flag.ExitOnError)
+	validateEntry := validateCmd.String("entry", "", "Entry template file")
+	validateData := validateCmd.String("data", "", "JSON data file or inline JSON")
+	validateWorkspace := validateCmd.String("workspace", ".", "Workspace directory")
+	validateFiles := validateCmd.String("files", "", "Comma-separated list of template files to include (if empty, auto-discover)")
+	validateStrict := validateCmd.Bool("strict", false, "Also fail when the data is missing paths the templates use")
+
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	exportConfig := exportCmd.String("config", "", "JSON configuration (same format as serve), or a path to a config file")
+	exportConfigFile := exportCmd.String("config-file", "", "JSON config file; keys in -config override it")
+	exportOut := exportCmd.String("out", "dist", "Output directory")
+	exportManifest := exportCmd.Bool("emit-manifest", false, "Write manifest.json listing every exported file")
+	exportSince := exportCmd.String("since", "", "Only re-render pages changed after this RFC 3339 timestamp (\"last\" uses the previous manifest)")
+
+	snapshotCmd := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	snapshotDir := snapshotCmd.String("dir", ".", "Directory of *.case.json files (entry, files, data, expected)")
+	snapshotUpdate := snapshotCmd.Bool("update", false, "Rewrite the expected output files from the current render")
+
+	funcsCmd := flag.NewFlagSet("funcs", flag.ExitOnError)
+	funcsJSON := funcsCmd.Bool("json", false, "Output as JSON")
+
+	scaffoldCmd := flag.NewFlagSet("scaffold", flag.ExitOnError)
+	scaffoldDir := scaffoldCmd.String("dir", ".", "Directory to create the project in (existing files are kept)")
 
 	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
-	serveConfig := serveCmd.String("config", "", "JSON configuration for the dev server")
+	serveConfig := serveCmd.String("config", "", "JSON configuration for the dev server, or a path to a config file")
+	serveConfigFile := serveCmd.String("config-file", "", "JSON config file; keys in -config override it")
+	servePrefix := serveCmd.String("prefix", "", "Mount all routes under a base path (e.g., /docs)")
+	servePreload := serveCmd.Bool("preload", false, "Parse every page into the template cache at startup and report parse errors")
+	serveOpen := serveCmd.Bool("open", false, "Open the default browser at the server URL once it is ready")
+	serveText := serveCmd.Bool("text", false, "Render pages with text/template (no escaping — trusted data only) for non-HTML output")
 
 	if len(os.Args) < 2 {
 		fmt.Fprintf(os.Stderr, "Usage: %s <command> [options]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Commands:\n")
 		fmt.Fprintf(os.Stderr, "  inspect  - Analyze template and output dependency graph\n")
 		fmt.Fprintf(os.Stderr, "  render   - Render template with data\n")
+		fmt.Fprintf(os.Stderr, "  validate - Check a data file against the paths and types a template uses\n")
 		fmt.Fprintf(os.Stderr, "  serve    - Start a filesystem-driven development server\n")
+		fmt.Fprintf(os.Stderr, "  export   - Render every page of the site to static HTML\n")
+		fmt.Fprintf(os.Stderr, "  snapshot - Render test cases and compare them with expected output\n")
+		fmt.Fprintf(os.Stderr, "  funcs    - List the helper functions available to templates\n")
+		fmt.Fprintf(os.Stderr, "  scaffold - Create a starter pages/layouts/partials project for serve\n")
 		os.Exit(1)
 	}
 
@@ -41,29 +103,126 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error: -entry flag is required\n")
 			os.Exit(1)
 		}
-		if err := runInspect(*inspectEntry, *inspectWorkspace, *inspectFiles); err != nil {
+		if err := runInspect(*inspectEntry, *inspectWorkspace, *inspectFiles, *inspectPageBlocks, *inspectDelims, *inspectFormat, *inspectSchema, *inspectDumpTree); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
 	case "render":
 		renderCmd.Parse(os.Args[2:])
-		if *renderEntry == "" {
+		if *renderEntry == "" && !*renderAll {
 			fmt.Fprintf(os.Stderr, "Error: -entry flag is required\n")
 			os.Exit(1)
 		}
-		if err := runRender(*renderEntry, *renderData, *renderWorkspace, *renderTemplate, *renderFiles); err != nil {
+		if *renderText {
+			*renderEscapeMode = "text"
+		}
+		opts := renderOptions{
+			overrides:            renderSet,
+			allowMissingIncludes: *renderAllowMissing,
+			includeMeta:          *renderIncludeMeta,
+			stats:                *renderStats,
+			escapeMode:           *renderEscapeMode,
+			rawFiles:             splitFilesArg(*renderRawFiles),
+			templateRoot:         *renderTemplateRoot,
+			strict:               *renderStrict,
+			outFile:              *renderOut,
+			delims:               *renderDelims,
+			contextPath:          *renderContextPath,
+			names:                *renderNames,
+			iconsDir:             *renderIconsDir,
+		}
+		if *renderAll {
+			if *renderOut == "" || *renderWatch {
+				fmt.Fprintf(os.Stderr, "Error: -all requires -out (a directory) and can't be combined with -watch\n")
+				os.Exit(1)
+			}
+			if err := runRenderAll(*renderWorkspace, *renderData, *renderDataDir, *renderTemplate, *renderFiles, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		render := func() error {
+			return runRender(*renderEntry, *renderData, *renderWorkspace, *renderTemplate, *renderFiles, *renderRepeat, opts)
+		}
+		reportError := func(err error) {
+			if *renderPrettyErrors && isTerminal(os.Stderr) {
+				printPrettyError(err, *renderEntry, *renderWorkspace, splitFilesArg(*renderFiles))
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}
+		if *renderWatch {
+			if err := watchRender(*renderEntry, *renderData, *renderWorkspace, *renderFiles, *renderOut, render, reportError); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := render(); err != nil {
+			reportError(err)
+			os.Exit(1)
+		}
+
+	case "validate":
+		validateCmd.Parse(os.Args[2:])
+		if *validateEntry == "" || *validateData == "" {
+			fmt.Fprintf(os.Stderr, "Error: -entry and -data flags are required\n")
+			os.Exit(1)
+		}
+		ok, err := runValidate(*validateEntry, *validateData, *validateWorkspace, *validateFiles, *validateStrict)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		if !ok {
+			os.Exit(1)
+		}
 
 	case "serve":
 		serveCmd.Parse(os.Args[2:])
-		if *serveConfig == "" {
-			fmt.Fprintf(os.Stderr, "Error: -config flag is required\n")
+		if *serveConfig == "" && *serveConfigFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: -config or -config-file flag is required\n")
+			os.Exit(1)
+		}
+		if err := runServe(*serveConfigFile, *serveConfig, *servePrefix, *servePreload, *serveOpen, *serveText); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		if err := runServe(*serveConfig); err != nil {
+
+	case "export":
+		exportCmd.Parse(os.Args[2:])
+		if *exportConfig == "" && *exportConfigFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: -config or -config-file flag is required\n")
+			os.Exit(1)
+		}
+		if err := runExport(*exportConfigFile, *exportConfig, *exportOut, *exportManifest, *exportSince); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "snapshot":
+		snapshotCmd.Parse(os.Args[2:])
+		ok, err := runSnapshot(*snapshotDir, *snapshotUpdate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+
+	case "funcs":
+		funcsCmd.Parse(os.Args[2:])
+		if err := runListFuncs(*funcsJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "scaffold":
+		scaffoldCmd.Parse(os.Args[2:])
+		if err := runScaffold(*scaffoldDir); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -74,8 +233,8 @@ func main() {
 	}
 }
 
-func runInspect(entryFile, workspace, filesArg string) error {
-	// Parse file list if provided
+// splitFilesArg parses a comma-separated -files flag value
+func splitFilesArg(filesArg string) []string {
 	var files []string
 	if filesArg != "" {
 		files = strings.Split(filesArg, ",")
@@ -83,13 +242,82 @@ func runInspect(entryFile, workspace, filesArg string) error {
 			files[i] = strings.TrimSpace(files[i])
 		}
 	}
+	return files
+}
+
+// parseDelims splits a "left right" delimiter spec such as "[[ ]]". An empty spec
+// returns empty strings, which keep Go's default {{ }}.
+func parseDelims(spec string) (string, string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return "", "", nil
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid delimiters %q (expected \"left right\", e.g. \"[[ ]]\")", spec)
+	}
+	return parts[0], parts[1], nil
+}
+
+// resolveRelativeToEntry rewrites relative paths that don't exist from the current
+// directory to the entry file's directory, when the file exists there
+func resolveRelativeToEntry(files []string, entryFile string) []string {
+	entryDir := filepath.Dir(entryFile)
+	for i, f := range files {
+		if filepath.IsAbs(f) {
+			continue
+		}
+		if _, err := os.Stat(f); err == nil {
+			continue
+		}
+		candidate := filepath.Join(entryDir, f)
+		if _, err := os.Stat(candidate); err == nil {
+			files[i] = candidate
+		}
+	}
+	return files
+}
+
+func runInspect(entryFile, workspace, filesArg, pageBlocksArg, delimsArg, format string, schema, dumpTree bool) error {
+	if format != "json" && format != "dot" && format != "mermaid" {
+		return fmt.Errorf("invalid -format %q (expected json, dot, or mermaid)", format)
+	}
+	left, right, err := parseDelims(delimsArg)
+	if err != nil {
+		return err
+	}
+
+	// Parse file list if provided
+	files := splitFilesArg(filesArg)
 
 	analyzer := NewTemplateAnalyzer(workspace)
+	analyzer.dumpTree = dumpTree
+	if blocks := splitFilesArg(pageBlocksArg); len(blocks) > 0 {
+		analyzer.pageBlocks = blocks
+	}
+	analyzer.SetDelims(left, right)
 	graph, err := analyzer.Analyze(entryFile, files)
 	if err != nil {
 		return err
 	}
 
+	if schema {
+		output, err := json.MarshalIndent(buildDataSchema(graph), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	switch format {
+	case "dot":
+		fmt.Print(formatGraphDOT(graph))
+		return nil
+	case "mermaid":
+		fmt.Print(formatGraphMermaid(graph))
+		return nil
+	}
+
 	output, err := json.MarshalIndent(graph, "", "  ")
 	if err != nil {
 		return err
@@ -99,37 +327,98 @@ func runInspect(entryFile, workspace, filesArg string) error {
 	return nil
 }
 
-func runRender(entryFile, dataSource, workspace, templateName, filesArg string) error {
+// renderOptions carries the optional render flags
+type renderOptions struct {
+	overrides            []string // -set key=value pairs
+	allowMissingIncludes bool
+	includeMeta          bool
+	stats                bool
+	escapeMode           string // "html" or "text"
+	rawFiles             []string
+	templateRoot         string // Name templates by path relative to this dir
+	strict               bool   // missingkey=error
+	outFile              string // Write the output here instead of stdout
+	delims               string // "left right" action delimiters
+	contextPath          string // Data path passed as dot, e.g. "Items[0]"
+	names                string // "base" or "relative" template naming
+	iconsDir             string // svgIcon root, like serve's iconsDir
+}
+
+// newRendererFromOptions builds a renderer configured by the render flags
+func newRendererFromOptions(workspace string, opts renderOptions) (*TemplateRenderer, error) {
+	if opts.escapeMode != "" && opts.escapeMode != "html" && opts.escapeMode != "text" {
+		return nil, fmt.Errorf("invalid -escape-mode %q (expected html or text)", opts.escapeMode)
+	}
+	left, right, err := parseDelims(opts.delims)
+	if err != nil {
+		return nil, err
+	}
+	if opts.names != "" && opts.names != "base" && opts.names != "relative" {
+		return nil, fmt.Errorf("invalid -names %q (expected base or relative)", opts.names)
+	}
+
 	renderer := NewTemplateRenderer(workspace)
+	renderer.allowMissingIncludes = opts.allowMissingIncludes
+	renderer.escapeMode = opts.escapeMode
+	renderer.rawFiles = opts.rawFiles
+	renderer.templateRoot = opts.templateRoot
+	renderer.strict = opts.strict
+	renderer.leftDelim, renderer.rightDelim = left, right
+	renderer.dataPath = opts.contextPath
+	renderer.relativeNames = opts.names == "relative"
+	renderer.iconsDir = opts.iconsDir
+	return renderer, nil
+}
 
-	var data map[string]interface{}
-	if dataSource != "" {
-		// Try to load as file first
-		fileData, err := os.ReadFile(dataSource)
-		if err == nil {
-			if err := json.Unmarshal(fileData, &data); err != nil {
-				return fmt.Errorf("invalid JSON in file: %v", err)
-			}
-		} else {
-			// Try to parse as inline JSON
-			if err := json.Unmarshal([]byte(dataSource), &data); err != nil {
-				return fmt.Errorf("invalid JSON data: %v", err)
-			}
+func runRender(entryFile, dataSource, workspace, templateName, filesArg, repeatArg string, opts renderOptions) error {
+	renderer, err := newRendererFromOptions(workspace, opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := loadDataArg(dataSource)
+	if err != nil {
+		return err
+	}
+
+	// Overlay -set values on top of the loaded data
+	if len(opts.overrides) > 0 && data == nil {
+		data = make(map[string]interface{})
+	}
+	for _, spec := range opts.overrides {
+		if err := applyDataOverride(data, spec); err != nil {
+			return err
 		}
 	}
 
-	// Parse files list if provided
-	var files []string
-	if filesArg != "" {
-		files = strings.Split(filesArg, ",")
-		for i := range files {
-			files[i] = strings.TrimSpace(files[i])
+	// Expand sample list items so list layouts render several rows
+	if repeatArg != "" {
+		if data == nil {
+			return fmt.Errorf("-repeat-data requires -data")
+		}
+		for _, spec := range splitFilesArg(repeatArg) {
+			path, countStr, ok := strings.Cut(spec, "=")
+			count, err := strconv.Atoi(countStr)
+			if !ok || err != nil || count < 1 {
+				return fmt.Errorf("invalid -repeat-data entry %q (expected path=count)", spec)
+			}
+			if err := repeatData(data, path, count); err != nil {
+				return err
+			}
 		}
 	}
 
+	// Parse files list if provided, resolving paths relative to the entry file when needed
+	files := resolveRelativeToEntry(splitFilesArg(filesArg), entryFile)
+
 	// Run validation first to collect all type mismatch errors at root level
-	// This skips fields inside range/with blocks to avoid false positives
-	validationErrors := renderer.ValidateData(entryFile, data, files)
+	// This skips fields inside range/with blocks to avoid false positives.
+	// With -context-path the entry's fields are relative to a sub-object, so the
+	// root-level check doesn't apply
+	var validationErrors []ValidationError
+	if opts.contextPath == "" {
+		validationErrors = renderer.ValidateData(entryFile, data, files)
+	}
 	if len(validationErrors) > 0 {
 		// Output all validation errors as a combined error message
 		var errMsgs []string
@@ -140,11 +429,340 @@ func runRender(entryFile, dataSource, workspace, templateName, filesArg string)
 		return fmt.Errorf("validation errors:\n%s", strings.Join(errMsgs, "\n"))
 	}
 
+	start := time.Now()
 	output, err := renderer.Render(entryFile, data, templateName, files)
 	if err != nil {
 		return err
 	}
+	elapsed := time.Since(start)
+
+	result := output
+	if opts.includeMeta {
+		result = renderMetaComment(entryFile, templateName, dataSource, renderer.loadedFiles) + output
+	}
+	if opts.outFile != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.outFile), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %v", err)
+		}
+		if err := os.WriteFile(opts.outFile, []byte(result), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", opts.outFile, err)
+		}
+	} else {
+		fmt.Print(result)
+	}
+
+	if opts.stats {
+		// Analyze just the files the render loaded, so this never rescans the workspace
+		analyzer := NewTemplateAnalyzer(workspace)
+		files := renderedFiles(entryFile, renderer.loadedFiles)
+		variables := 0
+		if graph, err := analyzer.Analyze(entryFile, files); err == nil {
+			variables = len(graph.Variables)
+		}
+		enc := json.NewEncoder(os.Stderr)
+		enc.Encode(RenderStats{
+			Bytes:      len(output),
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+			Templates:  len(files),
+			Variables:  variables,
+		})
+	}
+	return nil
+}
+
+// renderedFiles lists the entry and the files loaded with it once each. Auto-discovery
+// walks the workspace, so the entry is usually among the loaded files already.
+func renderedFiles(entryFile string, loaded []string) []string {
+	files := []string{entryFile}
+	seen := map[string]bool{sameFileKey(entryFile): true}
+	for _, path := range loaded {
+		if key := sameFileKey(path); !seen[key] {
+			seen[key] = true
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// sameFileKey normalizes path so different spellings of one file compare equal
+func sameFileKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// RenderStats is the machine-readable summary -stats prints after a render
+type RenderStats struct {
+	Bytes      int     `json:"bytes"`      // Length of the rendered output
+	DurationMs float64 `json:"durationMs"` // Time spent executing the templates
+	Templates  int     `json:"templates"`  // Template files loaded, including the entry
+	Variables  int     `json:"variables"`  // Distinct variable paths the templates reference
+}
+
+// renderMetaComment builds the provenance comment -include-meta prepends to the output
+func renderMetaComment(entryFile, templateName, dataSource string, included []string) string {
+	// A literal "-->" in a path would end the comment early
+	esc := func(s string) string { return strings.ReplaceAll(s, "-->", "--&gt;") }
+
+	var b strings.Builder
+	b.WriteString("<!--\n")
+	fmt.Fprintf(&b, "  entry: %s\n", esc(entryFile))
+	if templateName != "" {
+		fmt.Fprintf(&b, "  template: %s\n", esc(templateName))
+	}
+	b.WriteString("  included:")
+	if len(included) == 0 {
+		b.WriteString(" (none)")
+	}
+	b.WriteString("\n")
+	for _, file := range included {
+		fmt.Fprintf(&b, "    - %s\n", esc(file))
+	}
+	switch {
+	case dataSource == "":
+		b.WriteString("  data: (none)\n")
+	case fileExistsServe(dataSource):
+		fmt.Fprintf(&b, "  data: %s\n", esc(dataSource))
+	default:
+		b.WriteString("  data: (inline JSON)\n")
+	}
+	b.WriteString("-->\n")
+	return b.String()
+}
 
-	fmt.Print(output)
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
 	return nil
 }
+
+// loadDataArg loads a -data value as a JSON (or .json.gz) file path, falling back to inline JSON
+func loadDataArg(dataSource string) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if dataSource == "" {
+		return data, nil
+	}
+	// A comma-separated list of files is loaded in order and deep-merged
+	if files := dataSourceFiles(dataSource); len(files) > 1 {
+		for _, file := range files {
+			fileData, err := loadDataArg(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			if data == nil {
+				data = make(map[string]interface{})
+			}
+			deepMergeData(data, fileData)
+		}
+		return data, nil
+	}
+	// Try to load as file first
+	fileData, err := os.ReadFile(dataSource)
+	if err == nil {
+		if fileData, err = decompressData(dataSource, fileData); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fileData, &data); err != nil {
+			return nil, fmt.Errorf("invalid JSON in file: %v", err)
+		}
+	} else {
+		// Try to parse as inline JSON
+		if err := json.Unmarshal([]byte(dataSource), &data); err != nil {
+			return nil, fmt.Errorf("invalid JSON data: %v", err)
+		}
+	}
+	return data, nil
+}
+
+// dataSourceFiles splits a -data value into the files it names. Inline JSON is never
+// split (its commas aren't separators), so it comes back as a single entry.
+func dataSourceFiles(dataSource string) []string {
+	trimmed := strings.TrimSpace(dataSource)
+	if trimmed == "" {
+		return nil
+	}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return []string{dataSource}
+	}
+	return splitFilesArg(dataSource)
+}
+
+// deepMergeData copies src into dst. Objects present in both are merged recursively;
+// anything else in src (scalars, arrays) replaces the value in dst.
+func deepMergeData(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			deepMergeData(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// runValidate checks data against the variables the analyzer derives from the templates.
+// It prints every missing path and type mismatch and reports whether the data passed.
+func runValidate(entryFile, dataSource, workspace, filesArg string, strict bool) (bool, error) {
+	data, err := loadDataArg(dataSource)
+	if err != nil {
+		return false, err
+	}
+
+	files := resolveRelativeToEntry(splitFilesArg(filesArg), entryFile)
+
+	analyzer := NewTemplateAnalyzer(workspace)
+	graph, err := analyzer.Analyze(entryFile, files)
+	if err != nil {
+		return false, err
+	}
+
+	renderer := NewTemplateRenderer(workspace)
+	issues := renderer.CheckDataPaths(graph.Variables, data)
+
+	// Comparison checks from the renderer catch mismatches the analyzer can't see
+	reported := make(map[string]bool)
+	for _, issue := range issues {
+		reported[issue.Path] = true
+	}
+	for _, ve := range renderer.ValidateData(entryFile, data, files) {
+		if reported[ve.Path] {
+			continue
+		}
+		issues = append(issues, DataIssue{
+			Kind:     "mismatch",
+			Path:     ve.Path,
+			FilePath: fmt.Sprintf("%s:%d:%d", ve.File, ve.Line, ve.Column),
+			Message:  ve.Message,
+		})
+	}
+
+	passed := true
+	for _, issue := range issues {
+		fmt.Printf("%s: %s (%s)\n", issue.Kind, issue.Message, issue.FilePath)
+		if issue.Kind == "mismatch" || strict {
+			passed = false
+		}
+	}
+	if len(issues) == 0 {
+		fmt.Println("ok: data satisfies all template paths")
+	}
+	return passed, nil
+}
+
+// ANSI escape sequences for pretty error output
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+	ansiDim   = "\033[2m"
+)
+
+// templateErrorRe matches Go template error locations like "template: page.html:12:5: message"
+var templateErrorRe = regexp.MustCompile(`template: ([^:\s]+):(\d+)(?::(\d+))?: (.*)`)
+
+// isTerminal reports whether f is an interactive terminal and NO_COLOR is not set
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// printPrettyError writes err to stderr with ANSI colors, showing the offending
+// source line and a caret under the column for every template location found
+func printPrettyError(err error, entryFile, workspace string, files []string) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		match := templateErrorRe.FindStringSubmatch(line)
+		if match == nil {
+			fmt.Fprintf(os.Stderr, "%s%serror:%s %s\n", ansiBold, ansiRed, ansiReset, line)
+			continue
+		}
+
+		name, message := match[1], match[4]
+		lineNum, _ := strconv.Atoi(match[2])
+		col, _ := strconv.Atoi(match[3])
+
+		path := resolveTemplatePath(name, entryFile, workspace, files)
+		location := fmt.Sprintf("%s:%d", path, lineNum)
+		if col > 0 {
+			location += fmt.Sprintf(":%d", col)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s%serror:%s %s%s\n", ansiBold, ansiRed, ansiReset, ansiBold, message+ansiReset)
+		fmt.Fprintf(os.Stderr, "  %s-->%s %s%s%s\n", ansiDim, ansiReset, ansiCyan, location, ansiReset)
+
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			continue
+		}
+		sourceLines := strings.Split(string(content), "\n")
+		if lineNum < 1 || lineNum > len(sourceLines) {
+			continue
+		}
+		source := strings.TrimRight(sourceLines[lineNum-1], "\r")
+		gutter := strconv.Itoa(lineNum)
+		fmt.Fprintf(os.Stderr, "%s%s |%s %s\n", ansiDim, gutter, ansiReset, source)
+		if col > 0 && col <= len(source)+1 {
+			// Preserve tabs so the caret lines up with the source line
+			var pad strings.Builder
+			for _, r := range source[:col-1] {
+				if r == '\t' {
+					pad.WriteRune('\t')
+				} else {
+					pad.WriteRune(' ')
+				}
+			}
+			fmt.Fprintf(os.Stderr, "%s%s |%s %s%s^%s\n", ansiDim, strings.Repeat(" ", len(gutter)), ansiReset, pad.String(), ansiGreen, ansiReset)
+		}
+	}
+}
+
+// resolveTemplatePath maps a template name from an error message back to a file path,
+// checking the entry file, the explicit file list, then the workspace
+func resolveTemplatePath(name, entryFile, workspace string, files []string) string {
+	if filepath.Base(entryFile) == name {
+		return entryFile
+	}
+	// Names containing a slash come from -template-root and match by path suffix
+	matches := func(path string) bool {
+		return filepath.Base(path) == name || (strings.Contains(name, "/") && strings.HasSuffix(filepath.ToSlash(path), "/"+name))
+	}
+	for _, f := range files {
+		if matches(f) {
+			return f
+		}
+	}
+	found := ""
+	filepath.WalkDir(workspace, func(path string, d os.DirEntry, err error) error {
+		if err != nil || found != "" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			if path != workspace && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "dist") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matches(path) {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if found != "" {
+		return found
+	}
+	return name
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// The markdown helpers render CommonMark with goldmark. By default raw HTML in the
+// source is left out of the output and links with dangerous schemes (javascript: and
+// the like) are dropped; markdownUnsafe keeps both, for trusted content only.
+var (
+	safeMarkdown   = goldmark.New()
+	unsafeMarkdown = goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe()))
+)
+
+// markdownHTML renders Markdown with raw HTML omitted and unsafe links removed
+func markdownHTML(src string) template.HTML { return renderMarkdown(safeMarkdown, src) }
+
+// markdownUnsafeHTML renders Markdown and passes raw HTML through (trusted input only)
+func markdownUnsafeHTML(src string) template.HTML { return renderMarkdown(unsafeMarkdown, src) }
+
+// renderMarkdown converts src with md. Conversion only fails when writing fails, which
+// a bytes.Buffer never does, so whatever was produced is returned.
+func renderMarkdown(md goldmark.Markdown, src string) template.HTML {
+	var buf bytes.Buffer
+	md.Convert([]byte(src), &buf)
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mockRoute maps a URL pattern to the template (or file) that answers it
+type mockRoute struct {
+	pattern string // Exact path or path.Match glob, e.g. "/api/users/*"
+	target  string // Template name, or a file path as a fallback
+}
+
+// mockRoutes returns the configured mock routes, exact paths first and then the
+// longest (most specific) globs, so /api/users/me wins over /api/users/*
+func (s *DevServer) mockRoutes() ([]mockRoute, error) {
+	routes := make([]mockRoute, 0, len(s.cfg.MockRoutes))
+	for pattern, target := range s.cfg.MockRoutes {
+		if !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("mock route %q must start with /", pattern)
+		}
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return nil, fmt.Errorf("mock route %q: %w", pattern, err)
+		}
+		if target == "" {
+			return nil, fmt.Errorf("mock route %q has no template or file", pattern)
+		}
+		routes = append(routes, mockRoute{pattern: pattern, target: target})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		iGlob, jGlob := isGlobPattern(routes[i].pattern), isGlobPattern(routes[j].pattern)
+		if iGlob != jGlob {
+			return !iGlob
+		}
+		if len(routes[i].pattern) != len(routes[j].pattern) {
+			return len(routes[i].pattern) > len(routes[j].pattern)
+		}
+		return routes[i].pattern < routes[j].pattern
+	})
+	return routes, nil
+}
+
+// isGlobPattern reports whether a mock route pattern uses path.Match syntax
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[\\")
+}
+
+// mockHandler answers requests matching a mock route with an HTML fragment, so HTMX
+// and fetch calls to endpoints that don't exist yet still work in preview. Any other
+// request goes to next (pages, static files, the API proxy).
+func (s *DevServer) mockHandler(routes []mockRoute, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range routes {
+			if matched, _ := path.Match(route.pattern, r.URL.Path); matched {
+				s.serveMock(w, r, route)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveMock renders the route's template with the data of the page that made the
+// request (from HX-Current-URL or Referer, else "/"), using that page's template set.
+// When no template has the target's name, the target is served as a file relative to
+// the static root (or as given).
+func (s *DevServer) serveMock(w http.ResponseWriter, r *http.Request, route mockRoute) {
+	pagePath := s.requestingPage(r)
+
+	tmpl, err := s.pageTemplates(pagePath)
+	if err != nil {
+		log.Printf("❌ Mock %s: %v", r.URL.Path, err)
+		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if tmpl.Lookup(route.target) == nil {
+		file := s.mockFile(route.target)
+		if !fileExistsServe(file) {
+			http.Error(w, fmt.Sprintf("Mock route %s: no template or file named %q", route.pattern, route.target), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFile(w, r, file)
+		return
+	}
+
+	// Query parameters and ?set= overrides apply as they do on the page itself
+	data := s.pageRenderData(pagePath)
+	target, ok := data.(map[string]any)
+	if rd, isRenderData := data.(RenderData); isRenderData {
+		target, ok = rd.Data, true
+	}
+	if ok {
+		if err := applyQueryOverrides(target, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.executeTemplate(&buf, tmpl, route.target, data); err != nil {
+		log.Printf("❌ Mock render error: %v", err)
+		http.Error(w, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", s.pageContentType(buf.String()))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(buf.Bytes())
+}
+
+// requestingPage returns the unprefixed path of the page a fragment request came from.
+// HTMX sends HX-Current-URL; plain fetch calls usually carry a Referer.
+func (s *DevServer) requestingPage(r *http.Request) string {
+	for _, header := range []string{"HX-Current-URL", "Referer"} {
+		u, err := url.Parse(r.Header.Get(header))
+		if err != nil || u.Path == "" || (u.Host != "" && u.Host != r.Host) {
+			continue
+		}
+		p := u.Path
+		if s.cfg.Prefix != "" {
+			p = strings.TrimPrefix(p, s.cfg.Prefix)
+		}
+		if p == "" {
+			p = "/"
+		}
+		return p
+	}
+	return "/"
+}
+
+// pageTemplates loads the template set a full render of urlPath would use
+func (s *DevServer) pageTemplates(urlPath string) (*template.Template, error) {
+	if s.contextMode {
+		var pageFile string
+		if page := s.findContextPage(urlPath); page != nil {
+			pageFile = page.FilePath
+		}
+		return s.cachedTemplates(pageFile, s.loadContextTemplates)
+	}
+
+	s.mu.RLock()
+	root := s.root
+	s.mu.RUnlock()
+	templateFile := s.resolveTemplatePath(urlPath)
+	if page, _ := findPage(root, urlPath); page != nil {
+		templateFile = page.File
+	}
+	return s.cachedTemplates(templateFile, s.loadTemplates)
+}
+
+// mockFile resolves a mock route's file target against the static root
+func (s *DevServer) mockFile(target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	root := s.cfg.StaticDir
+	if s.contextMode {
+		root = s.cfg.ContentRoot
+	}
+	if root != "" {
+		if candidate := filepath.Join(root, filepath.FromSlash(target)); fileExistsServe(candidate) {
+			return candidate
+		}
+	}
+	return target
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// errorOverlayLight and errorOverlayDark are the overlay's color variables. Every
+// rule is scoped under .tv-error so nothing leaks into or out of page styles.
+const (
+	errorOverlayLight = `--tv-bg: #ffffff; --tv-fg: #1f2328; --tv-muted: #57606a; --tv-accent: #cf222e; --tv-code-bg: #f6f8fa; --tv-border: #d0d7de; --tv-link: #0969da;`
+	errorOverlayDark  = `--tv-bg: #0d1117; --tv-fg: #e6edf3; --tv-muted: #8d96a0; --tv-accent: #ff7b72; --tv-code-bg: #161b22; --tv-border: #30363d; --tv-link: #58a6ff;`
+)
+
+// errorOverlayCSS returns the overlay stylesheet for the configured color scheme:
+// "light" or "dark" force one palette, anything else follows prefers-color-scheme.
+func (s *DevServer) errorOverlayCSS() string {
+	var vars string
+	switch s.cfg.ColorScheme {
+	case "dark":
+		vars = `.tv-error { color-scheme: dark; ` + errorOverlayDark + ` }`
+	case "light":
+		vars = `.tv-error { color-scheme: light; ` + errorOverlayLight + ` }`
+	default:
+		vars = `.tv-error { color-scheme: light dark; ` + errorOverlayLight + ` }
+@media (prefers-color-scheme: dark) { .tv-error { ` + errorOverlayDark + ` } }`
+	}
+	return vars + `
+.tv-error { position: fixed; inset: 0; overflow: auto; margin: 0; padding: 32px; box-sizing: border-box;
+  background: var(--tv-bg); color: var(--tv-fg); font: 14px/1.5 system-ui, -apple-system, sans-serif; }
+.tv-error h1 { margin: 0 0 12px; font-size: 18px; color: var(--tv-accent); }
+.tv-error pre { margin: 0 0 16px; padding: 12px 16px; white-space: pre-wrap; word-break: break-word;
+  background: var(--tv-code-bg); border: 1px solid var(--tv-border); border-radius: 6px;
+  font: 13px/1.5 ui-monospace, SFMono-Regular, Menlo, monospace; color: var(--tv-fg); }
+.tv-error p { margin: 0 0 8px; color: var(--tv-muted); }
+.tv-error a { color: var(--tv-link); }`
+}
+
+// writeErrorOverlay responds with a readable error page for a failed page render.
+// It carries the live-reload script, so saving a fix brings the page back on its own.
+func (s *DevServer) writeErrorOverlay(w http.ResponseWriter, status int, title string, err error, urlPath string) {
+	scheme := "light dark"
+	if s.cfg.ColorScheme == "light" || s.cfg.ColorScheme == "dark" {
+		scheme = s.cfg.ColorScheme
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><meta name=\"color-scheme\" content=\"%s\"><title>", scheme)
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title>\n<style>\n")
+	b.WriteString(s.errorOverlayCSS())
+	b.WriteString("\n</style></head><body>\n<div class=\"tv-error\">\n<h1>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</h1>\n<pre>")
+	b.WriteString(html.EscapeString(err.Error()))
+	b.WriteString("</pre>\n")
+	fmt.Fprintf(&b, "<p><a href=\"%s\">Browse this page's data</a></p>\n", html.EscapeString(s.dataViewURL(urlPath)))
+	b.WriteString("<p>The page reloads when you save a fix.</p>\n</div>\n</body></html>")
+
+	nonce := s.newResponseNonce()
+	s.setCSPHeader(w, nonce)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprint(w, s.injectLiveReload(b.String(), nonce))
+}
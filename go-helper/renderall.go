@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// renderAllPage is a page template found by render -all, with the data it renders with
+type renderAllPage struct {
+	file     string // Page template path
+	dataFile string // Matching data file, or "" to use -data
+}
+
+// runRenderAll renders every page template under workspace into opts.outFile (a
+// directory), mirroring the source tree. Pages are found and matched to data files the
+// way serve does: a page defines {{define "content"}}, and its data file is the one in
+// dataDir whose _templateContext or name points at it. Templates that aren't pages are
+// loaded alongside each one (unless -files lists the shared templates), and templateName
+// is executed for every page. It defaults to the layout: the one shared template with
+// a {{block "content"}} for the pages to fill.
+func runRenderAll(workspace, dataSource, dataDir, templateName, filesArg string, opts renderOptions) error {
+	left, right, err := parseDelims(opts.delims)
+	if err != nil {
+		return err
+	}
+	if dataDir == "" {
+		dataDir = filepath.Join(workspace, ".vscode", "template-data")
+	}
+	outDir, err := filepath.Abs(opts.outFile)
+	if err != nil {
+		return err
+	}
+
+	// A server value gives access to serve's page heuristic and data file lookup
+	finder := &DevServer{cfg: ServeConfig{DataDir: dataDir}}
+	finder.defineRe = defineActionPattern(defaultDelims(left, right))
+
+	pages, shared, err := finder.collectRenderPages(workspace, outDir)
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("no page templates (with {{define \"content\"}}) found in %s", workspace)
+	}
+	if filesArg != "" {
+		shared = splitFilesArg(filesArg)
+	}
+
+	// A page file only defines blocks, so executing it on its own renders nothing
+	layout := ""
+	if templateName == "" {
+		if layout, err = findRenderLayout(shared, left, right); err != nil {
+			return err
+		}
+	}
+
+	baseData, err := loadDataArg(dataSource)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, page := range pages {
+		target, err := renderAllOutputPath(workspace, opts.outFile, page.file)
+		if err != nil {
+			return err
+		}
+		if err := renderAllPageTo(workspace, page, baseData, templateName, layout, shared, target, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", page.file, err)
+			failed++
+			continue
+		}
+		source := "no data"
+		if page.dataFile != "" {
+			source = filepath.Base(page.dataFile)
+		} else if dataSource != "" {
+			source = "-data"
+		}
+		fmt.Printf("  %s → %s (%s)\n", page.file, target, source)
+	}
+
+	fmt.Printf("\nRendered %d of %d page(s) into %s\n", len(pages)-failed, len(pages), opts.outFile)
+	if failed > 0 {
+		return fmt.Errorf("%d page(s) failed to render", failed)
+	}
+	return nil
+}
+
+// collectRenderPages walks workspace for template files, splitting them into pages
+// (with their data files) and the shared templates every page loads. Dot directories,
+// node_modules, dist, and the output directory are skipped.
+func (s *DevServer) collectRenderPages(workspace, outDir string) ([]renderAllPage, []string, error) {
+	var pages []renderAllPage
+	var shared []string
+	err := filepath.WalkDir(workspace, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != workspace && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist") {
+				return filepath.SkipDir
+			}
+			if abs, err := filepath.Abs(path); err == nil && abs == outDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isTemplateExt(filepath.Ext(path)) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if s.isContentPage(string(content)) {
+			pages = append(pages, renderAllPage{file: path, dataFile: s.findDataFileForPage(path)})
+		} else {
+			shared = append(shared, path)
+		}
+		return nil
+	})
+	sort.Slice(pages, func(i, j int) bool { return pages[i].file < pages[j].file })
+	return pages, shared, err
+}
+
+// findRenderLayout picks the layout -all executes when -template isn't given: the
+// shared template that declares a {{block}} for the "content" page block. None or
+// several is an error, since guessing would write empty or wrong pages.
+func findRenderLayout(shared []string, left, right string) (string, error) {
+	left, right = defaultDelims(left, right)
+	blockRe := regexp.MustCompile(regexp.QuoteMeta(left) + `-?\s*block\s+"` + defaultPageBlocks[0] + `"`)
+
+	var layouts []string
+	for _, file := range shared {
+		content, err := os.ReadFile(file)
+		if err == nil && blockRe.Match(content) {
+			layouts = append(layouts, file)
+		}
+	}
+	switch len(layouts) {
+	case 0:
+		return "", fmt.Errorf("no layout with {{block %q}} found; pass -template with the template to execute for each page", defaultPageBlocks[0])
+	case 1:
+		return layouts[0], nil
+	default:
+		return "", fmt.Errorf("several layouts declare {{block %q}} (%s); pass -template to pick one", defaultPageBlocks[0], strings.Join(layouts, ", "))
+	}
+}
+
+// isTemplateExt reports whether ext is one of the template extensions render discovers
+func isTemplateExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".html", ".tmpl", ".tpl", ".gohtml":
+		return true
+	}
+	return false
+}
+
+// renderAllOutputPath maps a page file to its output file: the same path relative to
+// workspace, under outDir, with .tmpl/.tpl/.gohtml written as .html
+func renderAllOutputPath(workspace, outDir, pageFile string) (string, error) {
+	rel, err := filepath.Rel(workspace, pageFile)
+	if err != nil {
+		return "", err
+	}
+	if ext := filepath.Ext(rel); !strings.EqualFold(ext, ".html") {
+		rel = strings.TrimSuffix(rel, ext) + ".html"
+	}
+	return filepath.Join(outDir, rel), nil
+}
+
+// renderAllPageTo renders one page with its data file (or the -data base data, with
+// -set overrides applied to either) and writes the result to target. Without a
+// templateName the layout file is executed, under the name the renderer gives it.
+func renderAllPageTo(workspace string, page renderAllPage, baseData map[string]interface{}, templateName, layout string, shared []string, target string, opts renderOptions) error {
+	renderer, err := newRendererFromOptions(workspace, opts)
+	if err != nil {
+		return err
+	}
+	if templateName == "" {
+		templateName = renderer.templateName(layout)
+	}
+
+	data := baseData
+	if page.dataFile != "" {
+		if data, err = loadDataArg(page.dataFile); err != nil {
+			return err
+		}
+	}
+	if len(opts.overrides) > 0 && data == nil {
+		data = make(map[string]interface{})
+	}
+	for _, spec := range opts.overrides {
+		if err := applyDataOverride(data, spec); err != nil {
+			return err
+		}
+	}
+
+	if validationErrors := renderer.ValidateData(page.file, data, shared); len(validationErrors) > 0 {
+		var errMsgs []string
+		for _, ve := range validationErrors {
+			errMsgs = append(errMsgs, fmt.Sprintf("%s:%d:%d: %s", filepath.Base(ve.File), ve.Line, ve.Column, ve.Message))
+		}
+		return fmt.Errorf("validation errors:\n%s", strings.Join(errMsgs, "\n"))
+	}
+
+	output, err := renderer.Render(page.file, data, templateName, shared)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) == "" {
+		return fmt.Errorf("template %q rendered no output for this page", templateName)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	return os.WriteFile(target, []byte(output), 0644)
+}
@@ -2,13 +2,22 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	texttemplate "text/template"
 	"text/template/parse"
+	"time"
 	"unicode"
 )
 
@@ -21,9 +30,52 @@ type ValidationError struct {
 	Path    string `json:"path"` // Variable path like "Security.SessionTimeout"
 }
 
+// DataIssue describes a problem found when checking data against analyzed template paths
+type DataIssue struct {
+	Kind     string `json:"kind"` // "missing" or "mismatch"
+	Path     string `json:"path"`
+	FilePath string `json:"filePath"`
+	Message  string `json:"message"`
+}
+
 // TemplateRenderer handles template rendering
 type TemplateRenderer struct {
 	workspace string
+
+	// allowMissingIncludes downgrades unreadable -files entries to warnings
+	allowMissingIncludes bool
+
+	// escapeMode "text" renders with text/template (no escaping); rawFiles opts out
+	// individual entry templates. Only for trusted data such as emails or snippets
+	escapeMode string
+	rawFiles   []string
+
+	// loadedFiles records the template files parsed alongside the entry, in load order
+	loadedFiles []string
+
+	// strict makes a missing map key fail the render (missingkey=error) instead of
+	// printing <no value>
+	strict bool
+
+	// templateRoot, when set, names templates by their slash-separated path relative to
+	// it ("a/index.html") instead of their basename, so same-named files don't collide
+	templateRoot string
+
+	// relativeNames (-names relative) names templates by their path relative to the
+	// workspace, or templateRoot when set, and also registers that path without its
+	// extension, so {{template "partials/card" .}} picks one of several card.html files
+	relativeNames bool
+
+	// leftDelim and rightDelim override the {{ }} action delimiters when set
+	leftDelim  string
+	rightDelim string
+
+	// iconsDir is where svgIcon looks for icons (-icons-dir); the workspace when unset
+	iconsDir string
+
+	// dataPath, when set, selects the value passed as dot (e.g. "Items[0]"), so a
+	// block or partial that expects a sub-object can be rendered on its own
+	dataPath string
 }
 
 func NewTemplateRenderer(workspace string) *TemplateRenderer {
@@ -32,13 +84,43 @@ func NewTemplateRenderer(workspace string) *TemplateRenderer {
 	}
 }
 
+// templateName returns the name a template file is parsed under: its path relative to
+// templateRoot (or the workspace with relativeNames) when the file is inside it,
+// otherwise its basename
+func (r *TemplateRenderer) templateName(path string) string {
+	rootDir := r.templateRoot
+	if rootDir == "" && r.relativeNames {
+		rootDir = r.workspace
+	}
+	if rootDir != "" {
+		root, rootErr := filepath.Abs(rootDir)
+		abs, absErr := filepath.Abs(path)
+		if rootErr == nil && absErr == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.Base(path)
+}
+
+// templateAlias returns the extra name a template is registered under with
+// relativeNames: its relative path without the extension ("partials/card"). Basenames
+// get no alias, so "card" never shadows a {{define "card"}}.
+func (r *TemplateRenderer) templateAlias(name string) string {
+	if !r.relativeNames || !strings.Contains(name, "/") {
+		return ""
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
 // ValidateData checks for type mismatches between template expectations and actual data
 // Returns a list of all validation errors found
 func (r *TemplateRenderer) ValidateData(entryFile string, data map[string]interface{}, files []string) []ValidationError {
 	var errors []ValidationError
 
 	// Parse templates to find comparison operations
-	tmpl := template.New("").Funcs(r.getTemplateFuncs())
+	tmpl := template.New("").Delims(r.leftDelim, r.rightDelim).Funcs(r.getTemplateFuncs())
 
 	// Load template files
 	if len(files) > 0 {
@@ -47,7 +129,7 @@ func (r *TemplateRenderer) ValidateData(entryFile string, data map[string]interf
 			if err != nil {
 				continue
 			}
-			tmpl.New(filepath.Base(path)).Parse(string(content))
+			tmpl.New(r.templateName(path)).Parse(string(content))
 		}
 	}
 
@@ -56,7 +138,7 @@ func (r *TemplateRenderer) ValidateData(entryFile string, data map[string]interf
 	if err != nil {
 		return errors
 	}
-	entryName := filepath.Base(entryFile)
+	entryName := r.templateName(entryFile)
 	tmpl.New(entryName).Parse(string(content))
 
 	// Check each template for comparison operations
@@ -72,7 +154,7 @@ func (r *TemplateRenderer) ValidateData(entryFile string, data map[string]interf
 			filePath = entryFile
 		} else {
 			for _, f := range files {
-				if filepath.Base(f) == fileName {
+				if r.templateName(f) == fileName {
 					filePath = f
 					break
 				}
@@ -284,6 +366,282 @@ func (r *TemplateRenderer) validatePipe(pipe *parse.PipeNode, data map[string]in
 	}
 }
 
+// CheckDataPaths reports analyzer variables that are missing from data or whose
+// data type contradicts how the template uses them. Only usages that pin a type
+// (comparisons, arithmetic, range) are checked for mismatches, since plain output
+// accepts any value.
+func (r *TemplateRenderer) CheckDataPaths(vars []Variable, data map[string]interface{}) []DataIssue {
+	var issues []DataIssue
+
+	sorted := append([]Variable{}, vars...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	for _, v := range sorted {
+		if v.Type == "variable" || strings.HasPrefix(v.Path, "$") {
+			continue
+		}
+
+		value, found, checkable := lookupDataPath(data, v.Path)
+		if !checkable {
+			// An empty array has no items to check fields against
+			continue
+		}
+		if !found {
+			issues = append(issues, DataIssue{
+				Kind:     "missing",
+				Path:     v.Path,
+				FilePath: v.FilePath,
+				Message:  fmt.Sprintf(".%s is used by the template but missing from the data", v.Path),
+			})
+			continue
+		}
+
+		expected := ""
+		switch v.Context {
+		case "eq-number", "gt-number":
+			expected = "number"
+		case "eq-string":
+			expected = "string"
+		case "eq-bool":
+			expected = "bool"
+		case "range-collection":
+			expected = "array"
+		}
+		if expected == "" || value == nil {
+			continue
+		}
+
+		actual := jsonTypeName(value)
+		if actual != expected && !(expected == "array" && actual == "object") {
+			issues = append(issues, DataIssue{
+				Kind:     "mismatch",
+				Path:     v.Path,
+				FilePath: v.FilePath,
+				Message:  fmt.Sprintf(".%s is used as %s but the data has %s", v.Path, expected, actual),
+			})
+		}
+	}
+
+	return issues
+}
+
+// lookupDataPath resolves an analyzer path like "Items[0].Name" in data. checkable is
+// false when the path runs through an empty array, where absence isn't an error.
+func lookupDataPath(data map[string]interface{}, path string) (value interface{}, found bool, checkable bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		isArray := strings.HasSuffix(part, "[0]")
+		name := strings.TrimSuffix(part, "[0]")
+
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false, true
+		}
+		current, ok = m[name]
+		if !ok {
+			return nil, false, true
+		}
+
+		if isArray {
+			items, ok := current.([]interface{})
+			if !ok {
+				return nil, false, true
+			}
+			if len(items) == 0 {
+				return nil, false, false
+			}
+			current = items[0]
+		}
+	}
+	return current, true, true
+}
+
+// selectDataPath resolves a -context-path like "Items[0].Author" or "Items.0.Author"
+// in data. Unlike lookupDataPath any index is allowed, and a missing step is an error
+// naming the part of the path that did resolve.
+func selectDataPath(data map[string]interface{}, dataPath string) (interface{}, error) {
+	dataPath = strings.TrimPrefix(strings.TrimSpace(dataPath), ".")
+	if dataPath == "" {
+		return data, nil
+	}
+	// Rewrite brackets as dotted steps: Items[0].Name -> Items.0.Name
+	steps := strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(dataPath), ".")
+
+	var current interface{} = data
+	for i, step := range steps {
+		if step == "" {
+			return nil, fmt.Errorf("invalid context path %q", dataPath)
+		}
+		resolved := strings.Join(steps[:i], ".")
+		if resolved == "" {
+			resolved = "."
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[step]
+			if !ok {
+				return nil, fmt.Errorf("context path %q: %s has no key %q", dataPath, resolved, step)
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(step)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("context path %q: index %q out of range for %s (length %d)", dataPath, step, resolved, len(v))
+			}
+			current = v[idx]
+		default:
+			return nil, fmt.Errorf("context path %q: %s is a %s, not an object or array", dataPath, resolved, jsonTypeName(v))
+		}
+	}
+	return current, nil
+}
+
+// matchTemplateName picks the template to execute: an exact name first, then a
+// name that equals the requested one once its extension is dropped
+func matchTemplateName(want string, names []string) string {
+	for _, name := range names {
+		if name == want {
+			return name
+		}
+	}
+	for _, name := range names {
+		if strings.TrimSuffix(name, path.Ext(name)) == want {
+			return name
+		}
+	}
+	return ""
+}
+
+// jsonTypeName names the JSON type of a decoded value
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat64(v); ok {
+		return "number"
+	}
+	return reflect.TypeOf(v).Kind().String()
+}
+
+// repeatData replaces the array at a dotted path with count varied copies of its first
+// item (or of the value itself when it's a single object), so list templates preview
+// with several rows
+func repeatData(data map[string]interface{}, path string, count int) error {
+	parts := strings.Split(path, ".")
+	parent := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := parent[part].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("repeat path %s: .%s is not an object", path, part)
+		}
+		parent = next
+	}
+
+	key := parts[len(parts)-1]
+	var sample interface{}
+	switch v := parent[key].(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			return fmt.Errorf("repeat path %s: array is empty, need one sample item", path)
+		}
+		sample = v[0]
+	case map[string]interface{}:
+		sample = v
+	case nil:
+		return fmt.Errorf("repeat path %s: not found in data", path)
+	default:
+		return fmt.Errorf("repeat path %s: expected an array or object, got %s", path, jsonTypeName(v))
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		items[i] = varySample(sample, i)
+	}
+	parent[key] = items
+	return nil
+}
+
+// applyDataOverride applies a key=value override to data. Dotted keys address nested
+// objects (page.title=Hi), creating them as needed; intermediate objects are copied
+// rather than modified, so data that shares nested maps with a cache stays untouched.
+// Values are typed like JSON: numbers, true/false, null, and [..]/{..} literals are
+// parsed, and anything else stays a string.
+func applyDataOverride(data map[string]interface{}, spec string) error {
+	key, raw, ok := strings.Cut(spec, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid override %q (expected key=value)", spec)
+	}
+
+	parts := strings.Split(key, ".")
+	parent := data
+	for _, part := range parts[:len(parts)-1] {
+		next := make(map[string]interface{})
+		switch existing := parent[part].(type) {
+		case map[string]interface{}:
+			for k, v := range existing {
+				next[k] = v
+			}
+		case nil:
+		default:
+			return fmt.Errorf("override %s: .%s is a %s, not an object", key, part, jsonTypeName(existing))
+		}
+		parent[part] = next
+		parent = next
+	}
+	parent[parts[len(parts)-1]] = parseOverrideValue(raw)
+	return nil
+}
+
+// parseOverrideValue infers the JSON type of an override value, defaulting to string
+func parseOverrideValue(raw string) interface{} {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return raw
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+		return v
+	}
+	return raw
+}
+
+// varySample deep-copies a sample value, varying it for copy n (0-based): strings get
+// a " n+1" suffix and numbers are offset by n, so repeated rows are distinguishable
+func varySample(v interface{}, n int) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = varySample(item, n)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = varySample(item, n)
+		}
+		return out
+	case string:
+		if n == 0 || val == "" {
+			return val
+		}
+		return fmt.Sprintf("%s %d", val, n+1)
+	case float64:
+		return val + float64(n)
+	default:
+		return val
+	}
+}
+
 // getNestedValue retrieves a nested value from a map using dot notation
 func (r *TemplateRenderer) getNestedValue(data map[string]interface{}, path string) interface{} {
 	parts := strings.Split(path, ".")
@@ -320,83 +678,170 @@ func (r *TemplateRenderer) posToLineCol(filePath string, pos int) (int, int) {
 }
 
 func (r *TemplateRenderer) Render(entryFile string, data map[string]interface{}, templateName string, files []string) (string, error) {
-	// Create a new template with helpful functions
-	tmpl := template.New("").Funcs(r.getTemplateFuncs())
+	content, err := os.ReadFile(entryFile)
+	if err != nil {
+		return "", err
+	}
+
+	// Create a new template with helpful functions. Raw (text) mode swaps in
+	// text/template with the same funcs, so nothing is escaped
+	// Delims set on the root carry over to every template added with New()
+	tmpl := template.New("").Delims(r.leftDelim, r.rightDelim).Funcs(r.getTemplateFuncs())
+	textTmpl := texttemplate.New("").Delims(r.leftDelim, r.rightDelim).Funcs(texttemplate.FuncMap(r.getTemplateFuncs()))
+	if r.strict {
+		// Set before any New() so every associated template inherits the option
+		tmpl.Option("missingkey=error")
+		textTmpl.Option("missingkey=error")
+	}
+	raw := r.isRawTemplate(entryFile, string(content))
+	addTemplate := func(name, text string) error {
+		if raw {
+			_, err := textTmpl.New(name).Parse(text)
+			if alias := r.templateAlias(name); err == nil && alias != "" {
+				_, err = textTmpl.New(alias).Parse(text)
+			}
+			return err
+		}
+		// The alias gets its own parse: html/template escapes each tree in place, so
+		// two names can't share one
+		_, err := tmpl.New(name).Parse(text)
+		if alias := r.templateAlias(name); err == nil && alias != "" {
+			_, err = tmpl.New(alias).Parse(text)
+		}
+		return err
+	}
 
 	// Load template files - either specific files or all in workspace
 	if len(files) > 0 {
 		// Load only the specified files
-		if err := r.loadSpecificTemplates(tmpl, files); err != nil {
+		if err := r.loadSpecificTemplates(addTemplate, files); err != nil {
 			return "", err
 		}
 	} else {
 		// Load all template files in workspace (auto-discover)
-		if err := r.loadTemplates(tmpl); err != nil {
+		if err := r.loadTemplates(addTemplate); err != nil {
 			return "", err
 		}
 	}
 
-	// Parse entry file with its basename as the template name
-	entryName := filepath.Base(entryFile)
-	content, err := os.ReadFile(entryFile)
-	if err != nil {
-		return "", err
-	}
-
-	entryTmpl, err := tmpl.New(entryName).Parse(string(content))
-	if err != nil {
+	// Parse entry file with its basename (or -template-root / -names relative path) as the name
+	entryName := r.templateName(entryFile)
+	if err := addTemplate(entryName, string(content)); err != nil {
 		return "", fmt.Errorf("parse error: %v", err)
 	}
 
 	// Determine which template to execute
-	var targetTmpl *template.Template
-	if templateName != "" {
-		// Look for specific template by name
-		targetTmpl = tmpl.Lookup(templateName)
-		if targetTmpl == nil {
-			return "", fmt.Errorf("template '%s' not found", templateName)
+	if templateName == "" {
+		// Use entry template
+		templateName = entryName
+	}
+	var targetTmpl interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+	var names []string
+	if raw {
+		for _, t := range textTmpl.Templates() {
+			if t.Name() != "" {
+				names = append(names, t.Name())
+			}
 		}
 	} else {
-		// Use entry template
-		targetTmpl = entryTmpl
+		for _, t := range tmpl.Templates() {
+			if t.Name() != "" {
+				names = append(names, t.Name())
+			}
+		}
+	}
+	// {{define}} and {{block}} names match exactly; a file name also matches without
+	// its extension, so -template card finds card.html
+	resolved := matchTemplateName(templateName, names)
+	if resolved != "" {
+		if raw {
+			targetTmpl = textTmpl.Lookup(resolved)
+		} else {
+			targetTmpl = tmpl.Lookup(resolved)
+		}
+	}
+	if targetTmpl == nil {
+		sort.Strings(names)
+		return "", fmt.Errorf("template '%s' not found (available: %s)", templateName, strings.Join(names, ", "))
+	}
+
+	var dot interface{} = data
+	if r.dataPath != "" {
+		dot, err = selectDataPath(data, r.dataPath)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	// Render using the target template
 	var buf bytes.Buffer
-	if err := targetTmpl.Execute(&buf, data); err != nil {
+	if err := targetTmpl.Execute(&buf, dot); err != nil {
+		if r.strict && strings.Contains(err.Error(), "map has no entry for key") {
+			return "", fmt.Errorf("render error: missing data key (strict mode): %v", err)
+		}
 		return "", fmt.Errorf("render error: %v", err)
 	}
 
 	return buf.String(), nil
 }
 
-func (r *TemplateRenderer) loadTemplates(tmpl *template.Template) error {
+// isRawTemplate reports whether entryFile renders without HTML escaping: -escape-mode
+// text, a .csv entry, a listing in -raw-files, or an {{/* escape: text */}} directive
+// in the file. Raw output is not safe for untrusted data — any HTML in the data is
+// emitted verbatim
+func (r *TemplateRenderer) isRawTemplate(entryFile, content string) bool {
+	if r.escapeMode == "text" || strings.EqualFold(filepath.Ext(entryFile), ".csv") {
+		return true
+	}
+	entryAbs, _ := filepath.Abs(entryFile)
+	for _, file := range r.rawFiles {
+		fileAbs, _ := filepath.Abs(file)
+		if file == filepath.Base(entryFile) || fileAbs == entryAbs {
+			return true
+		}
+	}
+	if r.leftDelim == "" && r.rightDelim == "" {
+		return rawDirectiveRe.MatchString(content)
+	}
+	left, right := defaultDelims(r.leftDelim, r.rightDelim)
+	directive := regexp.MustCompile(regexp.QuoteMeta(left) + `-?\s*/\*\s*escape:\s*text\s*\*/\s*-?` + regexp.QuoteMeta(right))
+	return directive.MatchString(content)
+}
+
+// rawDirectiveRe matches the {{/* escape: text */}} opt-out directive
+var rawDirectiveRe = regexp.MustCompile(`\{\{-?\s*/\*\s*escape:\s*text\s*\*/\s*-?\}\}`)
+
+func (r *TemplateRenderer) loadTemplates(addTemplate func(name, text string) error) error {
 	return filepath.WalkDir(r.workspace, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if d.IsDir() {
+			// The root itself is exempt: the default workspace "." starts with a dot
 			name := d.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist" {
+			if path != r.workspace && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "dist") {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".html" || ext == ".tmpl" || ext == ".tpl" || ext == ".gohtml" {
+		if isTemplateExt(filepath.Ext(path)) {
 			content, err := os.ReadFile(path)
 			if err != nil {
 				return nil // Skip files we can't read
 			}
 
 			// Parse as associated template
-			name := filepath.Base(path)
-			_, err = tmpl.New(name).Parse(string(content))
+			name := r.templateName(path)
+			err = addTemplate(name, string(content))
 			if err != nil {
 				// Log but don't fail
 				fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %v\n", path, err)
+			} else {
+				r.loadedFiles = append(r.loadedFiles, path)
 			}
 		}
 
@@ -404,164 +849,483 @@ func (r *TemplateRenderer) loadTemplates(tmpl *template.Template) error {
 	})
 }
 
-func (r *TemplateRenderer) loadSpecificTemplates(tmpl *template.Template, files []string) error {
+func (r *TemplateRenderer) loadSpecificTemplates(addTemplate func(name, text string) error, files []string) error {
 	for _, path := range files {
 		content, err := os.ReadFile(path)
 		if err != nil {
+			if r.allowMissingIncludes {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+				continue
+			}
 			return fmt.Errorf("failed to read %s: %v", path, err)
 		}
 
-		// Parse as associated template using basename
-		name := filepath.Base(path)
-		_, err = tmpl.New(name).Parse(string(content))
-		if err != nil {
+		// Parse as associated template using basename (or -template-root relative path)
+		name := r.templateName(path)
+		if err := addTemplate(name, string(content)); err != nil {
 			return fmt.Errorf("failed to parse %s: %v", path, err)
 		}
+		r.loadedFiles = append(r.loadedFiles, path)
 	}
 	return nil
 }
 
+// getTemplateFuncs returns the helpers available to rendered templates
 func (r *TemplateRenderer) getTemplateFuncs() template.FuncMap {
-	return template.FuncMap{
-		// Override comparison functions to handle JSON float64 vs int comparisons
-		// JSON unmarshals all numbers as float64, but template literals like 30 are int
-		"eq": flexibleEq,
-		"ne": flexibleNe,
-		"lt": flexibleLt,
-		"le": flexibleLe,
-		"gt": flexibleGt,
-		"ge": flexibleGe,
-
-		// Add common helper functions
-		"add": func(a, b int) int { return a + b },
-		"sub": func(a, b int) int { return a - b },
-		"mul": func(a, b int) int { return a * b },
-		"div": func(a, b int) int {
-			if b == 0 {
-				return 0
-			}
-			return a / b
-		},
-		"mod": func(a, b int) int {
-			if b == 0 {
-				return 0
-			}
-			return a % b
-		},
-		"upper": strings.ToUpper,
-		"lower": strings.ToLower,
-		"title": func(s string) string {
-			prev := ' '
-			return strings.Map(func(r rune) rune {
-				if unicode.IsSpace(rune(prev)) || unicode.IsPunct(rune(prev)) {
-					prev = r
-					return unicode.ToTitle(r)
-				}
-				prev = r
-				return r
-			}, s)
-		},
-		"trim":  strings.TrimSpace,
-		// Array/slice helpers - accept (index, slice) to check position
-		"isLast": func(i int, slice interface{}) bool {
-			v := reflect.ValueOf(slice)
-			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
-				return i == v.Len()-1
-			}
-			return false
-		},
-		"isFirst": func(i int) bool { return i == 0 },
-		"len": func(v interface{}) int {
-			rv := reflect.ValueOf(v)
-			switch rv.Kind() {
-			case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
-				return rv.Len()
-			default:
-				return 0
-			}
-		},
-		"seq": func(start, end int) []int {
-			var result []int
-			for i := start; i <= end; i++ {
-				result = append(result, i)
-			}
-			return result
-		},
-		// Safe slice function that handles out-of-range indices gracefully
-		"slice": func(item interface{}, indices ...int) interface{} {
-			v := reflect.ValueOf(item)
-			if !v.IsValid() {
-				return ""
-			}
+	return commonFuncMap(r)
+}
 
-			var start, end int
-			switch len(indices) {
-			case 1:
-				start = 0
-				end = indices[0]
-			case 2:
-				start = indices[0]
-				end = indices[1]
-			default:
-				return item
-			}
+// withPrefix links paths as given: a render has no mount prefix
+func (r *TemplateRenderer) withPrefix(urlPath string) string {
+	return urlPath
+}
 
-			switch v.Kind() {
-			case reflect.String:
-				s := v.String()
-				if start < 0 {
-					start = 0
-				}
-				if end > len(s) {
-					end = len(s)
-				}
-				if start >= end || start >= len(s) {
-					return ""
-				}
-				return s[start:end]
-			case reflect.Slice, reflect.Array:
-				length := v.Len()
-				if start < 0 {
-					start = 0
-				}
-				if end > length {
-					end = length
-				}
-				if start >= end || start >= length {
-					return reflect.MakeSlice(v.Type(), 0, 0).Interface()
-				}
-				return v.Slice(start, end).Interface()
-			default:
-				return item
+// svgIcon inlines an SVG from iconsDir, or from the workspace when it isn't set
+func (r *TemplateRenderer) svgIcon(name string, attrs ...any) (template.HTML, error) {
+	if len(attrs)%2 != 0 {
+		return "", fmt.Errorf("svgIcon %q: attributes must be key/value pairs", name)
+	}
+	dir := r.iconsDir
+	if dir == "" {
+		dir = r.workspace
+	}
+	svg, err := readSVGIcon(dir, name, os.ReadFile)
+	if err != nil {
+		return "", err
+	}
+	return applySVGAttrs(svg, attrs), nil
+}
+
+// b64img embeds an image from the workspace as a base64 data: URL
+func (r *TemplateRenderer) b64img(path string) (template.URL, error) {
+	return imageDataURL(r.workspace, path, os.ReadFile)
+}
+
+// Preset layouts for the time formatting helpers
+const (
+	dateLayout     = "2006-01-02"
+	timeLayout     = "15:04"
+	dateTimeLayout = "2006-01-02 15:04"
+)
+
+// toTime converts a template value to a time: time.Time passes through, strings are
+// parsed as RFC 3339 or a plain date, and numbers are Unix seconds (as JSON gives them)
+func toTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case *time.Time:
+		if val != nil {
+			return *val, true
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, dateTimeLayout, dateLayout} {
+			if t, err := time.Parse(layout, val); err == nil {
+				return t, true
 			}
-		},
-		// String helpers
-		"contains":  strings.Contains,
-		"hasPrefix": strings.HasPrefix,
-		"hasSuffix": strings.HasSuffix,
-		"replace":   strings.ReplaceAll,
-		"split":     strings.Split,
-		"join":      strings.Join,
-		// Safe HTML output
-		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
-		"safeJS":   func(s string) template.JS { return template.JS(s) },
-		"safeCSS":  func(s string) template.CSS { return template.CSS(s) },
-		"safeURL":  func(s string) template.URL { return template.URL(s) },
-		// Default value helper
-		"default": func(defaultVal, val interface{}) interface{} {
-			if val == nil || val == "" || val == 0 || val == false {
-				return defaultVal
+		}
+	default:
+		if secs, ok := toFloat64(v); ok {
+			return time.Unix(int64(secs), 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatTimeValue formats v with layout, passing strings it can't parse through unchanged
+func formatTimeValue(v interface{}, layout string) string {
+	if t, ok := toTime(v); ok {
+		return t.Format(layout)
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// formatDate formats a time as 2006-01-02
+func formatDate(v interface{}) string { return formatTimeValue(v, dateLayout) }
+
+// formatTime formats a time as 15:04
+func formatTime(v interface{}) string { return formatTimeValue(v, timeLayout) }
+
+// formatDateTime formats a time as 2006-01-02 15:04
+func formatDateTime(v interface{}) string { return formatTimeValue(v, dateTimeLayout) }
+
+// formatRFC3339 formats a time as RFC 3339 (2006-01-02T15:04:05Z07:00)
+func formatRFC3339(v interface{}) string { return formatTimeValue(v, time.RFC3339) }
+
+// formatTimeLayout formats a time with a Go layout; the layout comes first so it
+// works in pipelines: {{ now | date "2006-01-02" }}
+func formatTimeLayout(layout string, v interface{}) string { return formatTimeValue(v, layout) }
+
+// toDuration converts a count of unit to a duration: numbers, numeric strings, and
+// time.Duration values (which are taken as-is)
+func toDuration(v interface{}, unit time.Duration) (time.Duration, bool) {
+	if d, ok := v.(time.Duration); ok {
+		return d, true
+	}
+	n, ok := toFloat64(v)
+	if !ok {
+		s, isStr := v.(string)
+		if !isStr {
+			return 0, false
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, false
+		}
+		n = parsed
+	}
+	return time.Duration(n * float64(unit)), true
+}
+
+// durationString renders d with its two largest non-zero units ("2h 15m", "3d 4h",
+// "45s"); durations under a second are shown in milliseconds
+func durationString(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%s%dms", sign, d.Milliseconds())
+	}
+
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"},
+	}
+	var parts []string
+	for _, u := range units {
+		if n := d / u.size; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.suffix))
+			d -= n * u.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// humanizeDuration formats a number of seconds as a friendly duration: 8100 → "2h 15m"
+func humanizeDuration(seconds interface{}) string {
+	d, ok := toDuration(seconds, time.Second)
+	if !ok {
+		return ""
+	}
+	return durationString(d)
+}
+
+// formatDuration formats a number of milliseconds as a friendly duration: 1500 → "1s"
+func formatDuration(ms interface{}) string {
+	d, ok := toDuration(ms, time.Millisecond)
+	if !ok {
+		return ""
+	}
+	return durationString(d)
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesGlob reports whether s matches a shell glob pattern (*, ?, [a-z]).
+// Malformed patterns never match
+func matchesGlob(pattern, s string) bool {
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
+
+// queryString encodes a map as "?a=1&b=2" with keys sorted so output is diffable.
+// Slice values become repeated keys and nil values are skipped; an empty map gives ""
+func queryString(m interface{}) template.URL {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return ""
+	}
+
+	var b strings.Builder
+	add := func(key string, val reflect.Value) {
+		if b.Len() == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(fmt.Sprint(val.Interface())))
+	}
+	for _, k := range sortedMapKeys(v) {
+		val := v.MapIndex(k)
+		for val.Kind() == reflect.Interface && !val.IsNil() {
+			val = val.Elem()
+		}
+		if !val.IsValid() || (val.Kind() == reflect.Interface && val.IsNil()) {
+			continue
+		}
+		key := fmt.Sprint(k.Interface())
+		if val.Kind() == reflect.Slice || val.Kind() == reflect.Array {
+			for i := 0; i < val.Len(); i++ {
+				add(key, val.Index(i))
 			}
-			return val
-		},
-		// Conditional helpers
-		"ternary": func(cond bool, trueVal, falseVal interface{}) interface{} {
-			if cond {
-				return trueVal
+			continue
+		}
+		add(key, val)
+	}
+	return template.URL(b.String())
+}
+
+// urlJoin joins URL path segments with single slashes, keeping a leading slash or
+// scheme on the first segment: urlJoin "/docs/" "/guide" → "/docs/guide"
+func urlJoin(base string, parts ...string) string {
+	result := base
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		result = strings.TrimRight(result, "/") + "/" + strings.TrimLeft(part, "/")
+	}
+	return result
+}
+
+// csvField formats v as one RFC 4180 CSV field, quoting it when it contains a
+// comma, quote, or line break and doubling any embedded quotes
+func csvField(v interface{}) string {
+	s := ""
+	if v != nil {
+		s = fmt.Sprint(v)
+	}
+	if !strings.ContainsAny(s, ",\"\r\n") && strings.TrimSpace(s) == s {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// defaultValue returns defaultVal when val is nil or an empty string. Zero numbers
+// and false are legitimate values and are returned unchanged
+func defaultValue(defaultVal, val interface{}) interface{} {
+	if val == nil {
+		return defaultVal
+	}
+	if s, ok := val.(string); ok && s == "" {
+		return defaultVal
+	}
+	return val
+}
+
+// subslice returns item[start:end] for strings, slices, and arrays, clamping the
+// bounds instead of erroring. Empty or inverted ranges yield an empty value. Like
+// safeAt, strings are indexed by character, so a cut never splits one.
+func subslice(item interface{}, start, end int) interface{} {
+	v := reflect.ValueOf(item)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		runes := []rune(v.String())
+		if start < 0 {
+			start = 0
+		}
+		if end > len(runes) {
+			end = len(runes)
+		}
+		if start >= end || start >= len(runes) {
+			return ""
+		}
+		return string(runes[start:end])
+	case reflect.Slice, reflect.Array:
+		length := v.Len()
+		if start < 0 {
+			start = 0
+		}
+		if end > length {
+			end = length
+		}
+		if start >= end || start >= length {
+			return reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, 0).Interface()
+		}
+		if v.Kind() == reflect.Array && !v.CanAddr() {
+			// Unaddressable arrays can't be sliced directly
+			arr := reflect.New(v.Type()).Elem()
+			arr.Set(v)
+			v = arr
+		}
+		return v.Slice(start, end).Interface()
+	default:
+		return item
+	}
+}
+
+// safeAt returns the element at index i of a slice, array, or string, or nil when
+// i is out of range, so templates can peek at short lists without erroring. Strings
+// are indexed by character, so multi-byte text comes back whole.
+func safeAt(item interface{}, i int) interface{} {
+	v := reflect.ValueOf(item)
+	switch v.Kind() {
+	case reflect.String:
+		runes := []rune(v.String())
+		if i < 0 || i >= len(runes) {
+			return nil
+		}
+		return string(runes[i])
+	case reflect.Slice, reflect.Array:
+		if i < 0 || i >= v.Len() {
+			return nil
+		}
+		return v.Index(i).Interface()
+	default:
+		return nil
+	}
+}
+
+// sortedMapKeys returns the keys of a map value sorted by their string form
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// mapKeys returns the sorted keys of any map, or nil for non-map values
+func mapKeys(m interface{}) []interface{} {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil
+	}
+	var result []interface{}
+	for _, k := range sortedMapKeys(v) {
+		result = append(result, k.Interface())
+	}
+	return result
+}
+
+// mapValues returns the values of any map in sorted key order, or nil for non-map values
+func mapValues(m interface{}) []interface{} {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil
+	}
+	var result []interface{}
+	for _, k := range sortedMapKeys(v) {
+		result = append(result, v.MapIndex(k).Interface())
+	}
+	return result
+}
+
+// splitWords breaks s into lowercase words. Spaces, hyphens, and underscores are word
+// boundaries (as in serveTitleCase), as are camelCase humps and acronym ends ("HTTPServer")
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	for i, r := range runes {
+		if unicode.IsSpace(r) || r == '-' || r == '_' || r == '.' {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
 			}
-			return falseVal
-		},
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// capitalize upper-cases the first rune of a word
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// camelCase converts "user first-name" to "userFirstName"
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i := 1; i < len(words); i++ {
+		words[i] = capitalize(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// pascalCase converts "user first-name" to "UserFirstName"
+func pascalCase(s string) string {
+	words := splitWords(s)
+	for i := range words {
+		words[i] = capitalize(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// snakeCase converts "User FirstName" to "user_first_name"
+func snakeCase(s string) string {
+	return strings.Join(splitWords(s), "_")
+}
+
+// kebabCase converts "User FirstName" to "user-first-name"
+func kebabCase(s string) string {
+	return strings.Join(splitWords(s), "-")
+}
+
+// seqStep returns the integers from start to end inclusive, counting by the optional
+// step (default 1). A negative step counts down, so seq 10 0 -5 is [10 5 0].
+func seqStep(start, end int, step ...int) ([]int, error) {
+	by := 1
+	switch len(step) {
+	case 0:
+	case 1:
+		by = step[0]
+	default:
+		return nil, fmt.Errorf("expected start, end, and an optional step, got %d arguments", 2+len(step))
+	}
+	if by == 0 {
+		return nil, fmt.Errorf("step must not be 0")
+	}
+
+	var result []int
+	for i := start; (by > 0 && i <= end) || (by < 0 && i >= end); i += by {
+		result = append(result, i)
+	}
+	return result, nil
+}
+
+// floatOperands converts both operands of a float math helper
+func floatOperands(a, b interface{}) (float64, float64, error) {
+	x, ok := toFloat64(a)
+	if !ok {
+		return 0, 0, fmt.Errorf("%v is not a number", a)
+	}
+	y, ok := toFloat64(b)
+	if !ok {
+		return 0, 0, fmt.Errorf("%v is not a number", b)
 	}
+	return x, y, nil
 }
 
 // toFloat64 converts numeric types to float64 for comparison
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		name string
+		val  interface{}
+		want interface{}
+	}{
+		{"nil is missing", nil, "fallback"},
+		{"empty string is missing", "", "fallback"},
+		{"zero int is kept", 0, 0},
+		{"zero float from JSON is kept", 0.0, 0.0},
+		{"false is kept", false, false},
+		{"non-empty string is kept", "set", "set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultValue("fallback", tt.val); got != tt.want {
+				t.Errorf("defaultValue(%q, %#v) = %#v, want %#v", "fallback", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDefaultInTemplate guards the pipeline form, where the value arrives last:
+// {{ .Count | default 5 }} must print 0 for a zero count, not 5
+func TestDefaultInTemplate(t *testing.T) {
+	dir := t.TempDir()
+	entry := writeTestFile(t, dir, "page.html", `{{.Count | default 5}} {{.Enabled | default true}} {{.Missing | default "none"}} {{.Name | default "anon"}}`)
+
+	data := map[string]interface{}{"Count": 0.0, "Enabled": false, "Missing": nil, "Name": ""}
+	got, err := NewTemplateRenderer(dir).Render(entry, data, "", []string{entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 false none anon"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		in                          string
+		camel, pascal, snake, kebab string
+	}{
+		{"", "", "", "", ""},
+		{"user first name", "userFirstName", "UserFirstName", "user_first_name", "user-first-name"},
+		{"user-first-name", "userFirstName", "UserFirstName", "user_first_name", "user-first-name"},
+		{"user_first_name", "userFirstName", "UserFirstName", "user_first_name", "user-first-name"},
+		{"mixed_Case-words here", "mixedCaseWordsHere", "MixedCaseWordsHere", "mixed_case_words_here", "mixed-case-words-here"},
+		{"user  first--name__x", "userFirstNameX", "UserFirstNameX", "user_first_name_x", "user-first-name-x"},
+		{"  padded words  ", "paddedWords", "PaddedWords", "padded_words", "padded-words"},
+		{"-_-", "", "", "", ""},
+		{"HTTPServer id", "httpServerId", "HttpServerId", "http_server_id", "http-server-id"},
+		{"userFirstName", "userFirstName", "UserFirstName", "user_first_name", "user-first-name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := camelCase(tt.in); got != tt.camel {
+				t.Errorf("camelCase(%q) = %q, want %q", tt.in, got, tt.camel)
+			}
+			if got := pascalCase(tt.in); got != tt.pascal {
+				t.Errorf("pascalCase(%q) = %q, want %q", tt.in, got, tt.pascal)
+			}
+			if got := snakeCase(tt.in); got != tt.snake {
+				t.Errorf("snakeCase(%q) = %q, want %q", tt.in, got, tt.snake)
+			}
+			if got := kebabCase(tt.in); got != tt.kebab {
+				t.Errorf("kebabCase(%q) = %q, want %q", tt.in, got, tt.kebab)
+			}
+		})
+	}
+}
+
+func TestServeTitleCase(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"hello world", "Hello World"},
+		{"hello-world", "Hello-World"},
+		{"hello_world", "Hello_World"},
+		{"hello  world--again__now", "Hello  World--Again__Now"},
+		{"  padded  ", "  Padded  "},
+		{"-", "-"},
+	}
+	for _, tt := range tests {
+		if got := serveTitleCase(tt.in); got != tt.want {
+			t.Errorf("serveTitleCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContainsAny(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		substrs []string
+		want    bool
+	}{
+		{"one matches", "admin,editor", []string{"owner", "admin"}, true},
+		{"none match", "viewer", []string{"owner", "admin"}, false},
+		{"no substrings", "admin", nil, false},
+		{"empty string haystack", "", []string{"admin"}, false},
+		{"empty substring matches", "viewer", []string{""}, true},
+		{"case sensitive", "Admin", []string{"admin"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsAny(tt.s, tt.substrs...); got != tt.want {
+				t.Errorf("containsAny(%q, %q) = %v, want %v", tt.s, tt.substrs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSingularize(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"categories", "category"},
+		{"boxes", "box"},
+		{"items", "item"},
+		{"buses", "bus"},
+		{"statuses", "status"},
+		{"addresses", "address"},
+		{"houses", "house"},
+		{"uses", "use"},
+		{"Buses", "Bus"},
+		{"class", "class"},
+		{"bus", "bus"},
+	}
+	for _, tt := range tests {
+		if got := singularize(tt.word); got != tt.want {
+			t.Errorf("singularize(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSafeAt(t *testing.T) {
+	tests := []struct {
+		name string
+		item interface{}
+		i    int
+		want interface{}
+	}{
+		{"slice", []string{"a", "b"}, 1, "b"},
+		{"slice out of range", []string{"a"}, 1, nil},
+		{"negative index", []int{1, 2}, -1, nil},
+		{"array", [2]int{3, 4}, 0, 3},
+		{"ascii string", "abc", 2, "c"},
+		{"multi-byte string", "héllo", 1, "é"},
+		{"string counts characters", "日本", 1, "本"},
+		{"string out of range", "日本", 2, nil},
+		{"unsupported kind", 42, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeAt(tt.item, tt.i); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("safeAt(%v, %d) = %v, want %v", tt.item, tt.i, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubslice(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       interface{}
+		start, end int
+		want       interface{}
+	}{
+		{"slice", []int{1, 2, 3}, 1, 3, []int{2, 3}},
+		{"slice clamped", []int{1, 2}, -1, 5, []int{1, 2}},
+		{"slice inverted", []int{1, 2}, 2, 1, []int{}},
+		{"array", [3]string{"a", "b", "c"}, 0, 2, []string{"a", "b"}},
+		{"ascii string", "hello", 1, 3, "el"},
+		{"multi-byte string", "héllo", 0, 2, "hé"},
+		{"string counts characters", "日本語", 0, 1, "日"},
+		{"string clamped", "日本語", 1, 10, "本語"},
+		{"string inverted", "日本語", 2, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subslice(tt.item, tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subslice(%v, %d, %d) = %#v, want %#v", tt.item, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{5, "short", "short"},
+		{10, "short", "short"},
+		{5, "longer text", "longe…"},
+		{6, "longer text", "longer…"},
+		{7, "longer text", "longer…"},
+		{2, "日本語", "日本…"},
+		{3, "héllo", "hél…"},
+		{0, "abc", "…"},
+		{-1, "abc", "…"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.n, tt.s); got != tt.want {
+			t.Errorf("truncateRunes(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestWordWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		s    string
+		want string
+	}{
+		{"short string untouched", 20, "  a  b", "  a  b"},
+		{"wraps at spaces", 10, "the quick brown fox", "the quick\nbrown fox"},
+		{"keeps indentation", 10, "  indented line\nshort", "  indented\n  line\nshort"},
+		{"short lines untouched", 10, "a  b\nthe quick brown fox", "a  b\nthe quick\nbrown fox"},
+		{"keeps inner spacing", 12, "one  two   three four", "one  two\nthree four"},
+		{"long word on its own line", 4, "a abcdefgh b", "a\nabcdefgh\nb"},
+		{"counts runes", 6, "日本 語です 日本", "日本 語です\n日本"},
+		{"keeps trailing space", 5, "abc def ", "abc\ndef "},
+		{"zero width untouched", 0, "a b c", "a b c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wordWrap(tt.n, tt.s); got != tt.want {
+				t.Errorf("wordWrap(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*.png", "logo.png", true},
+		{"*.png", "logo.jpg", false},
+		{"img-?.png", "img-1.png", true},
+		{"img-[a-c].png", "img-b.png", true},
+		{"img-[a-c].png", "img-d.png", false},
+		{"*", "", true},
+		{"", "", true},
+		{"", "x", false},
+		{"*.png", "dir/logo.png", false},
+		{"[", "[", false},           // malformed pattern never matches
+		{"img-[a-", "img-a", false}, // unterminated class
+	}
+	for _, tt := range tests {
+		if got := matchesGlob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchesGlob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCSVField(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"plain", "plain"},
+		{"a,b", `"a,b"`},
+		{`say "hi"`, `"say ""hi"""`},
+		{"two\nlines", "\"two\nlines\""},
+		{" padded", `" padded"`},
+		{nil, ""},
+		{42.5, "42.5"},
+	}
+	for _, tt := range tests {
+		if got := csvField(tt.in); got != tt.want {
+			t.Errorf("csvField(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestRenderCSV renders a .csv entry, which uses text mode, and reads the output
+// back with encoding/csv so quoting mistakes show up as wrong fields
+func TestRenderCSV(t *testing.T) {
+	dir := t.TempDir()
+	entry := writeTestFile(t, dir, "export.csv", "name,quote,note\n"+
+		"{{range .Rows}}{{csvField .Name}},{{csvField .Quote}},{{csvField .Note}}\n{{end}}")
+
+	rows := []interface{}{
+		map[string]interface{}{"Name": "a,b", "Quote": `say "hi"`, "Note": "first\nsecond"},
+		map[string]interface{}{"Name": "<b>&</b>", "Quote": "", "Note": nil},
+	}
+	out, err := NewTemplateRenderer(dir).Render(entry, map[string]interface{}{"Rows": rows}, "", []string{entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v\n%s", err, out)
+	}
+	want := [][]string{
+		{"name", "quote", "note"},
+		{"a,b", `say "hi"`, "first\nsecond"},
+		{"<b>&</b>", "", ""}, // text mode: no HTML escaping
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestRenderedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  string
+		loaded []string
+		want   []string
+	}{
+		{"entry not loaded", "page.html", []string{"base.html"}, []string{"page.html", "base.html"}},
+		{"entry auto-discovered", "page.html", []string{"base.html", "page.html"}, []string{"page.html", "base.html"}},
+		{"different spelling", "./pages/page.html", []string{"pages/page.html", "base.html"}, []string{"./pages/page.html", "base.html"}},
+		{"nothing loaded", "page.html", nil, []string{"page.html"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderedFiles(tt.entry, tt.loaded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderedFiles(%q, %q) = %q, want %q", tt.entry, tt.loaded, got, tt.want)
+			}
+		})
+	}
+}
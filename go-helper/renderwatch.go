@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// renderWatchDebounce collapses the burst of events a single editor save produces
+const renderWatchDebounce = 100 * time.Millisecond
+
+// watchRender runs render once, then again after every change to the entry, the -files
+// templates (or any file under the workspace when -files is empty), and the data files.
+// Render errors are reported and watching continues, so a fix shows up on the next
+// save. It only returns if the watcher fails.
+func watchRender(entryFile, dataSource, workspace, filesArg, outFile string, render func() error, reportError func(error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch directories rather than files: editors that save by rename replace the
+	// file, which would silently drop a file-level watch
+	watched := make(map[string]bool)
+	watchDir := func(dir string) {
+		if !watched[dir] && dirExists(dir) {
+			watcher.Add(dir)
+			watched[dir] = true
+		}
+	}
+
+	set := renderWatchSet{targets: make(map[string]bool)}
+	if outFile != "" {
+		set.outFile, _ = filepath.Abs(outFile)
+	}
+	addTarget := func(path string) {
+		if abs, err := filepath.Abs(path); err == nil {
+			set.targets[abs] = true
+			watchDir(filepath.Dir(abs))
+		}
+	}
+	addTarget(entryFile)
+	files := resolveRelativeToEntry(splitFilesArg(filesArg), entryFile)
+	for _, f := range files {
+		addTarget(f)
+	}
+	for _, dataFile := range dataSourceFiles(dataSource) {
+		if info, err := os.Stat(dataFile); err == nil && !info.IsDir() {
+			addTarget(dataFile)
+		}
+	}
+
+	// Auto-discovery loads every template under the workspace, so any of them counts
+	if len(files) == 0 {
+		if abs, err := filepath.Abs(workspace); err == nil && dirExists(abs) {
+			set.root = abs
+			addRecursiveWatch(watcher, abs)
+		}
+	}
+
+	renderNow := func() {
+		// The separator goes to stderr when the output is a file, keeping the file clean
+		separator := fmt.Sprintf("── %s ─────────────────────────────\n", time.Now().Format("15:04:05"))
+		if outFile != "" {
+			fmt.Fprint(os.Stderr, separator)
+		} else {
+			fmt.Print(separator)
+		}
+		if err := render(); err != nil {
+			reportError(err)
+		} else if outFile != "" {
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", outFile)
+		}
+	}
+
+	renderNow()
+	fmt.Fprintln(os.Stderr, "Watching for changes (Ctrl+C to stop)...")
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) && !event.Has(fsnotify.Remove) {
+				continue
+			}
+			abs, err := filepath.Abs(event.Name)
+			if err != nil || !set.isRelevant(abs) {
+				continue
+			}
+			if event.Has(fsnotify.Create) && set.root != "" {
+				if info, err := os.Stat(abs); err == nil && info.IsDir() {
+					addRecursiveWatch(watcher, abs)
+				}
+			}
+			pending = time.After(renderWatchDebounce)
+		case <-pending:
+			pending = nil
+			renderNow()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("file watcher failed: %w", err)
+		}
+	}
+}
+
+// renderWatchSet is the set of absolute paths whose changes trigger a watched render
+type renderWatchSet struct {
+	targets map[string]bool // Entry, -files templates, and data files
+	root    string          // Workspace root when auto-discovering, or ""
+	outFile string          // The -out file, which never triggers a render
+}
+
+// isRelevant reports whether a change to path should re-render. The -out file is
+// excluded even under the workspace root, or each write would trigger the next render.
+func (w renderWatchSet) isRelevant(path string) bool {
+	if w.outFile != "" && path == w.outFile {
+		return false
+	}
+	if w.targets[path] {
+		return true
+	}
+	if w.root == "" || strings.HasPrefix(filepath.Base(path), ".") {
+		return false
+	}
+	rel, err := filepath.Rel(w.root, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderWatchSetIsRelevant(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(t.TempDir(), "data.json")
+	out := filepath.Join(root, "out.html")
+
+	discover := renderWatchSet{targets: map[string]bool{data: true}, root: root, outFile: out}
+	listed := renderWatchSet{targets: map[string]bool{filepath.Join(root, "page.html"): true}, outFile: out}
+
+	tests := []struct {
+		name string
+		set  renderWatchSet
+		path string
+		want bool
+	}{
+		{"template under the workspace", discover, filepath.Join(root, "partials", "nav.html"), true},
+		{"data file outside the workspace", discover, data, true},
+		{"output file under the workspace", discover, out, false},
+		{"dot file", discover, filepath.Join(root, ".page.html.swp"), false},
+		{"outside the workspace", discover, filepath.Join(filepath.Dir(root), "other.html"), false},
+		{"listed file", listed, filepath.Join(root, "page.html"), true},
+		{"unlisted file with -files", listed, filepath.Join(root, "other.html"), false},
+		{"output file with -files", listed, out, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.set.isRelevant(tt.path); got != tt.want {
+				t.Errorf("isRelevant(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
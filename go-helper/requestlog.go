@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Request log formats for ServeConfig.LogFormat
+const (
+	logFormatPretty = "pretty" // 📄 GET /path (the default)
+	logFormatPlain  = "plain"  // GET /path, without emoji
+	logFormatJSON   = "json"   // one JSON object per request, written when it completes
+)
+
+// statusRecorder remembers the status code a handler sends and when the handler was
+// entered, so the request log can show 500s apart from 200s and slow pages apart
+// from fast ones. http.Error goes through WriteHeader, so failed renders are captured.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	start       time.Time
+
+	// logged and kind are set by logRequest; requests it never saw (favicon) stay quiet
+	logged bool
+	kind   string
+}
+
+// newStatusRecorder wraps w, starting the clock; status is 200 until a handler says otherwise
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK, start: time.Now()}
+}
+
+// elapsed is the time since the handler was entered
+func (sr *statusRecorder) elapsed() time.Duration {
+	return time.Since(sr.start)
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if !sr.wroteHeader {
+		sr.status = status
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if !sr.wroteHeader {
+		sr.WriteHeader(http.StatusOK)
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush passes through to the wrapped writer so streaming still works
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// requestLogEntry is one line of the json request log
+type requestLogEntry struct {
+	Time       string  `json:"time"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// validLogFormat reports whether format is a LogFormat the server understands
+func validLogFormat(format string) bool {
+	switch format {
+	case "", logFormatPretty, logFormatPlain, logFormatJSON:
+		return true
+	}
+	return false
+}
+
+// logRequest marks a page request for the log once it completes; kind notes a special
+// route such as "csv". w is the handler's writer, a *statusRecorder in page handlers.
+func (s *DevServer) logRequest(w http.ResponseWriter, kind string) {
+	if rec, ok := w.(*statusRecorder); ok {
+		rec.logged = true
+		rec.kind = kind
+	}
+}
+
+// logRequestDone logs a finished request with its status and duration. The json line
+// goes straight to the log's writer, without the timestamp prefix, so each line parses
+// on its own; json mode also logs requests logRequest never marked.
+func (s *DevServer) logRequestDone(r *http.Request, rec *statusRecorder) {
+	elapsed := rec.elapsed()
+	if s.cfg.LogFormat == logFormatJSON {
+		line, err := json.Marshal(requestLogEntry{
+			Time:       rec.start.UTC().Format(time.RFC3339Nano),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     rec.status,
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+		})
+		if err == nil {
+			fmt.Fprintf(log.Writer(), "%s\n", line)
+		}
+		return
+	}
+	if !rec.logged {
+		return
+	}
+
+	suffix := ""
+	if rec.kind != "" {
+		suffix = " (" + rec.kind + ")"
+	}
+	took := elapsed.Round(10 * time.Microsecond)
+	if s.cfg.LogFormat == logFormatPlain {
+		log.Printf("%s %s%s %d %s", r.Method, r.URL.Path, suffix, rec.status, took)
+		return
+	}
+	icon := "📄"
+	if rec.status >= http.StatusInternalServerError {
+		icon = "❌"
+	}
+	log.Printf("%s %s %s%s → %d in %s", icon, r.Method, r.URL.Path, suffix, rec.status, took)
+}
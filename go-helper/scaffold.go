@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scaffoldFile is one file written by the scaffold command, relative to the project dir
+type scaffoldFile struct {
+	path    string
+	content string
+}
+
+// scaffoldConfig is the serve config the scaffold writes; paths are relative to the
+// project directory, so serve is run from there
+const scaffoldConfig = `{
+  "pagesDir": "pages",
+  "layoutsDir": "layouts",
+  "partialsDir": "partials",
+  "staticDir": "static",
+  "layoutFile": "base.html",
+  "port": 3000
+}
+`
+
+// scaffoldFiles is a minimal convention-mode project: a layout with a content block,
+// a nav partial, two pages with titles, and a stylesheet
+var scaffoldFiles = []scaffoldFile{
+	{"serve.json", scaffoldConfig},
+	{"layouts/base.html", `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Page.Title}}</title>
+  <link rel="stylesheet" href="{{url "/static/style.css"}}">
+</head>
+<body>
+  {{template "nav" .}}
+  <main>
+    {{block "content" .}}<p>This page has no content block.</p>{{end}}
+  </main>
+</body>
+</html>
+`},
+	{"partials/nav.html", `{{define "nav"}}
+<nav>
+  <a href="{{url "/"}}"{{if isActive $.Path "/"}} class="active"{{end}}>Home</a>
+  {{range .Site.Pages}}
+  <a href="{{.Path}}"{{if isActive $.Path .Path}} class="active"{{end}}>{{.Title}}</a>
+  {{end}}
+</nav>
+{{end}}
+`},
+	{"pages/index.html", `{{define "content"}}
+<h1>{{.Page.Title}}</h1>
+<p>Edit <code>pages/index.html</code> and the page reloads on save.</p>
+{{end}}
+`},
+	{"pages/index.json", `{
+  "title": "Home",
+  "order": 1
+}
+`},
+	{"pages/about.html", `{{define "content"}}
+<h1>{{.Page.Title}}</h1>
+<p>Each file in <code>pages/</code> is a page; a JSON file next to it sets its title and order.</p>
+{{end}}
+`},
+	{"pages/about.json", `{
+  "title": "About",
+  "order": 2
+}
+`},
+	{"static/style.css", `body { font-family: system-ui, sans-serif; margin: 0; }
+nav { display: flex; gap: 16px; padding: 16px 24px; border-bottom: 1px solid #ddd; }
+nav a { color: inherit; text-decoration: none; }
+nav a.active { font-weight: 600; }
+main { padding: 24px; }
+`},
+}
+
+// runScaffold writes the starter project into dir, creating it if needed. Existing
+// files are left untouched and reported as skipped, so it is safe to re-run.
+func runScaffold(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	fmt.Printf("%s/\n", strings.TrimSuffix(filepath.ToSlash(dir), "/"))
+	created := 0
+	for _, f := range scaffoldFiles {
+		target := filepath.Join(dir, filepath.FromSlash(f.path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			fmt.Printf("  %-20s (exists, skipped)\n", f.path)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		_, err = file.WriteString(f.content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		fmt.Printf("  %s\n", f.path)
+		created++
+	}
+
+	fmt.Printf("\nCreated %d file(s). Start the dev server with:\n", created)
+	fmt.Printf("  cd %s && %s serve -config serve.json\n", dir, filepath.Base(os.Args[0]))
+	return nil
+}
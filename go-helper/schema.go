@@ -0,0 +1,119 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// schemaNode is one level of the JSON Schema built from variable paths. Its type is
+// decided by structure first (properties → object, items → array), then by the
+// analyzer's inferred leaf type.
+type schemaNode struct {
+	Type       string
+	Properties map[string]*schemaNode
+	Items      *schemaNode
+	Example    interface{}
+}
+
+// buildDataSchema converts the analyzer's variables into a JSON Schema (draft 2020-12)
+// describing the data the templates expect. Dotted paths become nested properties and
+// [0] segments become array items, so "BrandApps[0].Domain" yields an array of objects
+// with a string Domain property.
+func buildDataSchema(graph *TemplateGraph) map[string]interface{} {
+	root := &schemaNode{Type: "object"}
+	for _, v := range graph.Variables {
+		if v.Type == "variable" || strings.HasPrefix(v.Path, "$") {
+			continue // Template variables ($x) aren't part of the data
+		}
+		node := root
+		for _, seg := range splitSchemaPath(v.Path) {
+			if seg == "[]" {
+				if node.Items == nil {
+					node.Items = &schemaNode{}
+				}
+				node = node.Items
+				continue
+			}
+			if node.Properties == nil {
+				node.Properties = make(map[string]*schemaNode)
+			}
+			child, ok := node.Properties[seg]
+			if !ok {
+				child = &schemaNode{}
+				node.Properties[seg] = child
+			}
+			node = child
+		}
+		// inferType marks nested fields of range items as "object" by path shape; the
+		// leaf itself is a scalar (nested objects come from properties above)
+		varType, example := v.Type, v.Suggested
+		if varType == "object" && v.Context == "range" {
+			varType, example = "string", nil
+		}
+		// A specific type (number, bool) beats the default "string" guess
+		if node.Type == "" || node.Type == "string" {
+			node.Type = varType
+			if example != nil && example != "" && node.Example == nil {
+				node.Example = example
+			}
+		}
+	}
+
+	schema := root.toJSON()
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = filepath.Base(graph.EntryFile)
+	return schema
+}
+
+// splitSchemaPath splits "Apps[0].Tags[1]" into ["Apps", "[]", "Tags", "[]"]
+func splitSchemaPath(path string) []string {
+	var segs []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open < 0 {
+				segs = append(segs, part)
+				break
+			}
+			if open > 0 {
+				segs = append(segs, part[:open])
+			}
+			end := strings.Index(part[open:], "]")
+			if end < 0 {
+				break
+			}
+			segs = append(segs, "[]")
+			part = part[open+end+1:]
+		}
+	}
+	return segs
+}
+
+func (n *schemaNode) toJSON() map[string]interface{} {
+	out := make(map[string]interface{})
+	switch {
+	case len(n.Properties) > 0:
+		out["type"] = "object"
+		props := make(map[string]interface{}, len(n.Properties))
+		for name, child := range n.Properties {
+			props[name] = child.toJSON()
+		}
+		out["properties"] = props
+	case n.Items != nil:
+		out["type"] = "array"
+		out["items"] = n.Items.toJSON()
+	default:
+		switch n.Type {
+		case "bool":
+			out["type"] = "boolean"
+		case "number", "object", "array", "string":
+			out["type"] = n.Type
+		default:
+			out["type"] = "string"
+		}
+		if n.Example != nil && n.Type != "object" && n.Type != "array" {
+			out["examples"] = []interface{}{n.Example}
+		}
+	}
+	return out
+}
@@ -2,17 +2,33 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
+	"mime"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	texttemplate "text/template"
+	"time"
 	"unicode"
 
 	"github.com/fsnotify/fsnotify"
@@ -56,6 +72,10 @@ type RenderData struct {
 	Slug string         `json:"slug,omitempty"`
 	Path string         `json:"path"`
 	Data map[string]any `json:"data,omitempty"`
+	// Nonce is the per-response CSP nonce (empty unless cspNonce is enabled)
+	Nonce string `json:"nonce,omitempty"`
+	// Prefix is the base path all routes are mounted under ("" at the domain root)
+	Prefix string `json:"prefix,omitempty"`
 }
 
 // PageMeta represents metadata loaded from sidecar JSON files.
@@ -77,12 +97,113 @@ type ServeConfig struct {
 	IndexFile   string `json:"indexFile"`
 	Port        int    `json:"port"`
 
+	// IndexNames lists directory index filenames in priority order (default: index.html)
+	IndexNames []string `json:"indexNames,omitempty"`
+
 	// Context-driven mode: uses the extension's render context instead of convention dirs
 	ContextFiles []string `json:"contextFiles,omitempty"` // Files from the render context (entry + included)
 	EntryFile    string   `json:"entryFile,omitempty"`    // The entry/base template file
 	DataFile     string   `json:"dataFile,omitempty"`     // Linked .vscode/template-data JSON file
 	DataDir      string   `json:"dataDir,omitempty"`      // .vscode/template-data directory for auto-discovery
 	ContentRoot  string   `json:"contentRoot,omitempty"` // Content root for static asset resolution
+
+	// Override the {{define "content"}} page heuristic in context mode. Entries match a
+	// file's full path, its path relative to the entry file's directory, or its basename.
+	ForceShared []string `json:"forceShared,omitempty"` // Always load as shared (layout/partial)
+	ForcePages  []string `json:"forcePages,omitempty"`  // Always treat as a navigable page
+
+	// PageBlocks lists the layout override points (e.g., "content", "sidebar", "scripts").
+	// A file that defines any of them is a page in context mode (default: content)
+	PageBlocks []string `json:"pageBlocks,omitempty"`
+
+	// Delims overrides the {{ }} action delimiters as "left right" (e.g., "[[ ]]")
+	Delims string `json:"delims,omitempty"`
+
+	// Content-Security-Policy: nonce the injected live-reload script and optionally send a header
+	CSPNonce  bool   `json:"cspNonce,omitempty"`  // Generate a per-response nonce for inline scripts
+	CSPHeader bool   `json:"cspHeader,omitempty"` // Send a Content-Security-Policy header matching the nonce
+	CSPPolicy string `json:"cspPolicy,omitempty"` // Custom policy; "{nonce}" is replaced with the response nonce
+
+	// Snapshot mode: read changed files into memory before reloading (for sshfs/NFS editing)
+	Snapshot bool `json:"snapshot,omitempty"`
+
+	// Prefix mounts every route under a base path (e.g., "/docs") to emulate subpath deployment
+	Prefix string `json:"prefix,omitempty"`
+
+	// TrailingSlash sets canonical page URLs: "never" redirects /apps/ → /apps,
+	// "always" redirects /apps → /apps/, and "" (default) serves both without redirecting
+	TrailingSlash string `json:"trailingSlash,omitempty"`
+
+	// RequireEntry fails startup when there is nothing to render (missing entry file or
+	// empty pages directory) instead of starting a server that errors on every request
+	RequireEntry bool `json:"requireEntry,omitempty"`
+
+	// IconsDir holds the SVG files the svgIcon helper inlines (e.g., "icons/check.svg")
+	IconsDir string `json:"iconsDir,omitempty"`
+
+	// API proxy: requests under ProxyPrefix (default "/api/") are forwarded to ProxyTarget
+	ProxyTarget string `json:"proxyTarget,omitempty"` // Backend base URL, e.g. "http://localhost:8080"
+	ProxyPrefix string `json:"proxyPrefix,omitempty"`
+
+	// MockRoutes answers fragment requests with a template rendered against the calling
+	// page's data, e.g. {"/api/users/*": "user-list"}. Patterns are exact paths or
+	// path.Match globs; a target that names no template is served as a file
+	MockRoutes map[string]string `json:"mockRoutes,omitempty"`
+
+	// CacheTemplates keeps parsed template sets between requests until a file changes,
+	// instead of reparsing on every request. Preload implies it and also parses every
+	// page at startup (and after each change) so parse errors surface immediately
+	CacheTemplates bool `json:"cacheTemplates,omitempty"`
+	Preload        bool `json:"preload,omitempty"`
+
+	// NegotiateJSON returns a page's render data as JSON instead of HTML when the
+	// request's Accept header prefers application/json over text/html
+	NegotiateJSON bool `json:"negotiateJSON,omitempty"`
+
+	// ColorScheme forces the error overlay palette: "light", "dark", or "" to follow
+	// the browser's prefers-color-scheme
+	ColorScheme string `json:"colorScheme,omitempty"`
+
+	// NavTemplate names the template rendered by the /__nav fragment endpoint (default: "nav")
+	NavTemplate string `json:"navTemplate,omitempty"`
+
+	// HTTPS: serve TLS with the given PEM certificate and key, or with a certificate for
+	// localhost generated at startup when TLSSelfSigned is set (browsers will warn)
+	TLSCert       string `json:"tlsCert,omitempty"`
+	TLSKey        string `json:"tlsKey,omitempty"`
+	TLSSelfSigned bool   `json:"tlsSelfSigned,omitempty"`
+
+	// HTTP Basic auth for every route except /__reload; both must be set to enable it
+	BasicAuthUser string `json:"basicAuthUser,omitempty"`
+	BasicAuthPass string `json:"basicAuthPass,omitempty"`
+
+	// NotFoundFile is a template rendered (with layout and nav data) for unknown pages,
+	// with status 404. If it is missing or fails to render, the plain 404 is sent
+	NotFoundFile string `json:"notFoundFile,omitempty"`
+
+	// OpenBrowser launches the default browser at the server URL once it is listening
+	OpenBrowser bool `json:"openBrowser,omitempty"`
+
+	// EnvFile is a .env file (KEY=VALUE lines) whose keys are added to .Env as-is,
+	// without the TEMPLATEDEV_ prefix. The process environment wins for the same key
+	EnvFile string `json:"envFile,omitempty"`
+
+	// StrictContext renders with exactly ContextFiles and EntryFile: no pages/ walk and
+	// no shared-template scan of the entry's sibling directories
+	StrictContext bool `json:"strictContext,omitempty"`
+
+	// DisableDirListing makes the static and asset file servers answer 404 for a
+	// directory without an index.html instead of listing its files (and sources)
+	DisableDirListing bool `json:"disableDirListing,omitempty"`
+
+	// TextMode executes pages with text/template instead of html/template, for config
+	// files and plain-text emails. Nothing is escaped, so only use it with trusted data.
+	// Output that isn't an HTML document is served as text/plain without live reload
+	TextMode bool `json:"textMode,omitempty"`
+
+	// LogFormat selects how page requests are logged: "pretty" (default, with emoji),
+	// "plain" (no emoji), or "json" (one object per request with status and duration)
+	LogFormat string `json:"logFormat,omitempty"`
 }
 
 // DevServer is the development HTTP server.
@@ -93,8 +214,12 @@ type DevServer struct {
 	mu      sync.RWMutex
 	watcher *fsnotify.Watcher
 
+	// envOnlyDir is the EnvFile's directory when it is watched just for that file, so
+	// other changes there (a project root, say) don't reload the page
+	envOnlyDir string
+
 	// SSE clients for live reload
-	sseClients   map[chan struct{}]struct{}
+	sseClients   map[chan reloadEvent]struct{}
 	sseClientsMu sync.Mutex
 
 	// Listener for port detection
@@ -110,6 +235,28 @@ type DevServer struct {
 	contextPages  []*ContextPage // All navigable pages discovered from the workspace
 	sharedFiles   []string       // Layout/partial files from the context (non-page templates)
 	contextPageMu sync.RWMutex
+
+	// Export mode: pages are rendered to disk, so skip dev-only injections
+	exporting bool
+
+	// Snapshot mode: in-memory copies of changed files, swapped atomically on each change
+	snapshot   map[string][]byte
+	snapshotMu sync.RWMutex
+
+	// Analyzer sample data cached per template file set, cleared on any change
+	sampleCache   map[string]map[string]any
+	sampleCacheMu sync.Mutex
+
+	// SVG icon sources cached by name for svgIcon, cleared on any change
+	iconCache   map[string]string
+	iconCacheMu sync.Mutex
+
+	// Parsed template sets cached by page file when caching is enabled, cleared on any change
+	templateCache   map[string]*template.Template
+	templateCacheMu sync.Mutex
+
+	// defineRe matches {{define "name"}} actions in the configured delimiters
+	defineRe *regexp.Regexp
 }
 
 // ContextPage represents a navigable page discovered from the workspace.
@@ -122,10 +269,44 @@ type ContextPage struct {
 
 // ── Server lifecycle ────────────────────────────────────────────────────────
 
-func runServe(configJSON string) error {
-	var cfg ServeConfig
-	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
-		return fmt.Errorf("invalid config JSON: %w", err)
+func runServe(configFile, configJSON, prefix string, preload, open, text bool) error {
+	cfg, err := loadServeConfig(configFile, configJSON)
+	if err != nil {
+		return err
+	}
+
+	// The -prefix flag overrides the config value
+	if prefix != "" {
+		cfg.Prefix = prefix
+	}
+	cfg.Prefix = normalizePrefix(cfg.Prefix)
+
+	// The -preload flag turns on preloading (and with it, the template cache)
+	if preload {
+		cfg.Preload = true
+	}
+
+	// The -open flag opens the browser once the server is listening
+	if open {
+		cfg.OpenBrowser = true
+	}
+
+	// The -text flag switches page execution to text/template
+	if text {
+		cfg.TextMode = true
+	}
+
+	if _, _, err := parseDelims(cfg.Delims); err != nil {
+		return err
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return fmt.Errorf("tlsCert and tlsKey must be set together")
+	}
+	if (cfg.BasicAuthUser == "") != (cfg.BasicAuthPass == "") {
+		return fmt.Errorf("basicAuthUser and basicAuthPass must be set together")
+	}
+	if !validLogFormat(cfg.LogFormat) {
+		return fmt.Errorf("invalid logFormat %q (expected pretty, plain, or json)", cfg.LogFormat)
 	}
 
 	if cfg.Port == 0 {
@@ -134,7 +315,7 @@ func runServe(configJSON string) error {
 
 	// Auto-detect index file if not provided
 	if cfg.IndexFile == "" {
-		cfg.IndexFile = autoDetectIndex(cfg.PagesDir)
+		cfg.IndexFile = autoDetectIndex(cfg.PagesDir, cfg.indexNames())
 	}
 
 	srv, err := newDevServer(cfg)
@@ -147,10 +328,19 @@ func runServe(configJSON string) error {
 
 func newDevServer(cfg ServeConfig) (*DevServer, error) {
 	s := &DevServer{
-		cfg:         cfg,
-		sseClients:  make(map[chan struct{}]struct{}),
-		contextMode: len(cfg.ContextFiles) > 0 && cfg.EntryFile != "",
-		contextData: make(map[string]any),
+		cfg:           cfg,
+		sseClients:    make(map[chan reloadEvent]struct{}),
+		contextMode:   len(cfg.ContextFiles) > 0 && cfg.EntryFile != "",
+		contextData:   make(map[string]any),
+		snapshot:      make(map[string][]byte),
+		sampleCache:   make(map[string]map[string]any),
+		iconCache:     make(map[string]string),
+		templateCache: make(map[string]*template.Template),
+	}
+	s.defineRe = defineActionPattern(cfg.delims())
+
+	if cfg.Snapshot {
+		log.Println("📸 Snapshot mode enabled (changed files are read into memory before reload)")
 	}
 
 	if s.contextMode {
@@ -169,9 +359,55 @@ func newDevServer(cfg ServeConfig) (*DevServer, error) {
 		}
 	}
 
+	if err := s.checkRenderable(); err != nil {
+		if cfg.RequireEntry {
+			return nil, err
+		}
+		log.Printf("⚠️  %v", err)
+	}
+
+	if cfg.Preload {
+		s.preloadTemplates()
+	}
+
 	return s, nil
 }
 
+// checkRenderable reports a startup error with guidance when the server has nothing
+// it could render: a missing entry file in context mode, or no page templates in
+// convention mode.
+func (s *DevServer) checkRenderable() error {
+	if s.contextMode {
+		if !fileExistsServe(s.cfg.EntryFile) {
+			return fmt.Errorf("entry file %s does not exist — reopen the preview from an existing template or update the render context", s.cfg.EntryFile)
+		}
+		return nil
+	}
+
+	if !dirExists(s.cfg.PagesDir) {
+		return fmt.Errorf("pages directory %s does not exist — create it or set pagesDir in the config", s.cfg.PagesDir)
+	}
+	var hasPage func(p *Page) bool
+	hasPage = func(p *Page) bool {
+		if p.File != "" {
+			return true
+		}
+		for _, child := range p.Children {
+			if hasPage(child) {
+				return true
+			}
+		}
+		return false
+	}
+	s.mu.RLock()
+	root := s.root
+	s.mu.RUnlock()
+	if root == nil || !hasPage(root) {
+		return fmt.Errorf("no page templates found in %s — add an %s or set pagesDir in the config", s.cfg.PagesDir, s.cfg.indexNames()[0])
+	}
+	return nil
+}
+
 func (s *DevServer) start() error {
 	// Start file watcher
 	if err := s.startWatcher(); err != nil {
@@ -180,16 +416,20 @@ func (s *DevServer) start() error {
 		log.Println("👁  Watching for file changes...")
 	}
 
+	if s.cfg.TextMode {
+		log.Println("📝 Text mode: pages render with text/template and nothing is escaped")
+	}
+
 	// Set up routes
 	mux := http.NewServeMux()
 
 	// Static file server — serve from staticDir (convention mode) or contentRoot (context mode)
 	if s.contextMode && s.cfg.ContentRoot != "" && dirExists(s.cfg.ContentRoot) {
-		fsHandler := http.FileServer(http.Dir(s.cfg.ContentRoot))
+		fsHandler := s.fileServer(s.cfg.ContentRoot)
 		mux.Handle("/static/", http.StripPrefix("/static/", fsHandler))
 		log.Printf("📁 Serving static files from %s at /static/", s.cfg.ContentRoot)
 	} else if !s.contextMode && dirExists(s.cfg.StaticDir) {
-		fsHandler := http.FileServer(http.Dir(s.cfg.StaticDir))
+		fsHandler := s.fileServer(s.cfg.StaticDir)
 		mux.Handle("/static/", http.StripPrefix("/static/", fsHandler))
 		log.Printf("📁 Serving static files from %s at /static/", s.cfg.StaticDir)
 	}
@@ -201,18 +441,70 @@ func (s *DevServer) start() error {
 		// Check for an assets directory next to the entry file
 		assetsDir := filepath.Join(entryDir, "assets")
 		if dirExists(assetsDir) {
-			assetHandler := http.FileServer(http.Dir(entryDir))
+			assetHandler := s.fileServer(entryDir)
 			mux.Handle("/assets/", assetHandler)
 			log.Printf("📁 Serving assets from %s at /assets/", assetsDir)
 		}
 	}
 
+	// /.well-known/ files (ACME challenges, apple-app-site-association, security.txt)
+	// are served as-is from the content root, like a production host would
+	mux.HandleFunc("/.well-known/", s.handleWellKnown)
+
+	// Forward API calls to a running backend so HTMX/fetch requests work without CORS
+	if s.cfg.ProxyTarget != "" {
+		proxy, err := s.apiProxy()
+		if err != nil {
+			return err
+		}
+		mux.Handle(s.proxyPrefix(), proxy)
+		log.Printf("🔀 Proxying %s* to %s", s.proxyPrefix(), s.cfg.ProxyTarget)
+	}
+
 	// SSE endpoint for live reload
 	mux.HandleFunc("/__reload", s.handleSSE)
 
+	// Analyzer-generated sample data for a page (?path=/apps)
+	mux.HandleFunc("/__sample-data", s.handleSampleData)
+	mux.HandleFunc("/__nav", s.handleNav)
+
+	// Browsable view of the merged render data for a page (?path=/apps or /__data/apps),
+	// as JSON with ?format=json
+	mux.HandleFunc("/__data", s.handleDataView)
+	mux.HandleFunc("/__data/", s.handleDataView)
+
 	// Template handler (catch-all)
 	mux.HandleFunc("/", s.handlePage)
 
+	var handler http.Handler = mux
+
+	// Mock routes take precedence over pages and the API proxy
+	if len(s.cfg.MockRoutes) > 0 {
+		routes, err := s.mockRoutes()
+		if err != nil {
+			return err
+		}
+		handler = s.mockHandler(routes, handler)
+		for _, route := range routes {
+			log.Printf("🎭 Mocking %s with %s", route.pattern, route.target)
+		}
+	}
+
+	// Compress text responses (the /__reload stream is left alone)
+	handler = compressHandler(handler)
+
+	// Require credentials when basic auth is configured
+	if s.cfg.BasicAuthUser != "" && s.cfg.BasicAuthPass != "" {
+		handler = s.basicAuthHandler(handler)
+		log.Printf("🔐 Basic auth enabled for user %q", s.cfg.BasicAuthUser)
+	}
+
+	// Mount everything under the prefix when one is configured
+	if s.cfg.Prefix != "" {
+		handler = s.prefixHandler(handler)
+		log.Printf("📌 Mounting all routes under %s/", s.cfg.Prefix)
+	}
+
 	// Listen on the configured port with fallback
 	ln, err := listenWithFallback(s.cfg.Port)
 	if err != nil {
@@ -220,15 +512,216 @@ func (s *DevServer) start() error {
 	}
 	s.listener = ln
 
+	// Wrap the listener in TLS before announcing the port, so a bad certificate
+	// fails startup instead of every request
+	if s.cfg.useTLS() {
+		tlsConfig, err := s.tlsConfig()
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		ln = tls.NewListener(ln, tlsConfig)
+		s.listener = ln
+	}
+
 	// Output the actual port (important for the extension to detect)
 	actualPort := ln.Addr().(*net.TCPAddr).Port
-	fmt.Fprintf(os.Stdout, "SERVE_READY|port=%d\n", actualPort)
+	if s.cfg.useTLS() {
+		fmt.Fprintf(os.Stdout, "SERVE_READY|port=%d|scheme=https\n", actualPort)
+	} else {
+		fmt.Fprintf(os.Stdout, "SERVE_READY|port=%d\n", actualPort)
+	}
+	s.writeServeStatus(actualPort)
 	if actualPort != s.cfg.Port {
 		log.Printf("⚠️  Port %d was in use, using port %d instead", s.cfg.Port, actualPort)
 	}
-	log.Printf("✅ Server ready at http://localhost:%d", actualPort)
+	log.Printf("✅ Server ready at %s://localhost:%d", s.cfg.scheme(), actualPort)
+	if s.cfg.OpenBrowser {
+		openBrowser(s.serverURL(actualPort))
+	}
+
+	return http.Serve(ln, handler)
+}
+
+// loadServeConfig reads the config file (if any), then applies the inline JSON on top:
+// only the keys present inline override the file. An inline value that isn't a JSON
+// object is taken as the config file path, so -config config.json works too.
+func loadServeConfig(configFile, configJSON string) (ServeConfig, error) {
+	var cfg ServeConfig
+	if trimmed := strings.TrimSpace(configJSON); trimmed != "" && !strings.HasPrefix(trimmed, "{") {
+		if configFile != "" {
+			return cfg, fmt.Errorf("-config %q is not a JSON object (use -config-file for the file and -config for inline overrides)", configJSON)
+		}
+		configFile, configJSON = trimmed, ""
+	}
+
+	if configFile != "" {
+		raw, err := os.ReadFile(configFile)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return cfg, fmt.Errorf("invalid config file %s: %w", configFile, err)
+		}
+	}
+	if strings.TrimSpace(configJSON) != "" {
+		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+			return cfg, fmt.Errorf("invalid config JSON: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// ServeStatus is the machine-readable startup report printed after SERVE_READY as
+// SERVE_STATUS|{json}, so the extension can read the bound port and mode without
+// parsing log lines.
+type ServeStatus struct {
+	RequestedPort int    `json:"requestedPort"`
+	Port          int    `json:"port"`
+	PortChanged   bool   `json:"portChanged"` // The requested port was in use
+	Mode          string `json:"mode"`        // "context" or "convention"
+	Prefix        string `json:"prefix,omitempty"`
+	Scheme        string `json:"scheme"` // "http" or "https"
+	URL           string `json:"url"`
+}
+
+func (s *DevServer) writeServeStatus(port int) {
+	mode := "convention"
+	if s.contextMode {
+		mode = "context"
+	}
+	status := ServeStatus{
+		RequestedPort: s.cfg.Port,
+		Port:          port,
+		PortChanged:   port != s.cfg.Port,
+		Mode:          mode,
+		Prefix:        s.cfg.Prefix,
+		Scheme:        s.cfg.scheme(),
+		URL:           s.serverURL(port),
+	}
+	out, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stdout, "SERVE_STATUS|%s\n", out)
+}
+
+// serverURL is the root URL of the running server, including the mount prefix
+func (s *DevServer) serverURL(port int) string {
+	return fmt.Sprintf("%s://localhost:%d%s/", s.cfg.scheme(), port, s.cfg.Prefix)
+}
+
+// openBrowser opens target in the default browser with the platform's opener. Failures
+// (e.g., no opener on a headless CI machine) are logged and otherwise ignored.
+func openBrowser(target string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("⚠️  Could not open a browser: %v", err)
+		return
+	}
+	log.Printf("🌐 Opened %s in the browser", target)
+	// Reap the opener so it doesn't linger as a zombie
+	go cmd.Wait()
+}
+
+// proxyPrefix returns the path prefix forwarded to the API proxy, always ending in "/".
+func (s *DevServer) proxyPrefix() string {
+	prefix := s.cfg.ProxyPrefix
+	if prefix == "" {
+		prefix = "/api/"
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// apiProxy builds the reverse proxy for ProxyTarget. Request paths are kept as-is
+// (/api/users → target/api/users) and the Host header is rewritten to the target.
+// A path under the prefix that matches a template page is still rendered locally.
+func (s *DevServer) apiProxy() (http.Handler, error) {
+	target, err := url.Parse(s.cfg.ProxyTarget)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid proxyTarget %q: expected a base URL like http://localhost:8080", s.cfg.ProxyTarget)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = target.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("❌ Proxy error for %s: %v", r.URL.Path, err)
+		http.Error(w, fmt.Sprintf("Proxy error: %v", err), http.StatusBadGateway)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.pageExists(r.URL.Path) {
+			s.handlePage(w, r)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}), nil
+}
+
+// pageExists reports whether urlPath renders a template page rather than a 404
+func (s *DevServer) pageExists(urlPath string) bool {
+	if s.contextMode {
+		return s.findContextPage(urlPath) != nil
+	}
+	entryFile, _ := s.templateFilesForPath(urlPath)
+	return entryFile != "" && fileExistsServe(entryFile)
+}
+
+// prefixHandler strips the configured prefix before dispatching to next.
+// The bare prefix redirects to prefix + "/", and paths outside the prefix are 404s.
+func (s *DevServer) prefixHandler(next http.Handler) http.Handler {
+	stripped := http.StripPrefix(s.cfg.Prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == s.cfg.Prefix:
+			http.Redirect(w, r, s.cfg.Prefix+"/", http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, s.cfg.Prefix+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
 
-	return http.Serve(ln, mux)
+// basicAuthHandler rejects requests without the configured Basic auth credentials.
+// /__reload stays open: EventSource can't send credentials on its own, and the stream
+// only carries file names.
+func (s *DevServer) basicAuthHandler(next http.Handler) http.Handler {
+	wantUser := []byte(s.cfg.BasicAuthUser)
+	wantPass := []byte(s.cfg.BasicAuthPass)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/__reload" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		// Compare both fields every time so timing doesn't reveal which one was wrong
+		userOK := subtle.ConstantTimeCompare([]byte(user), wantUser) == 1
+		passOK := subtle.ConstantTimeCompare([]byte(pass), wantPass) == 1
+		if !ok || !userOK || !passOK {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Template dev server", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // ── File watcher ────────────────────────────────────────────────────────────
@@ -294,6 +787,34 @@ func (s *DevServer) startWatcher() error {
 		}
 	}
 
+	// Icons inlined by svgIcon live outside the template dirs
+	if s.cfg.IconsDir != "" && dirExists(s.cfg.IconsDir) {
+		addRecursiveWatch(w, s.cfg.IconsDir)
+	}
+
+	// Static roots, so stylesheet edits can be hot-swapped
+	for _, dir := range s.staticRoots() {
+		addRecursiveWatch(w, dir)
+	}
+
+	// The env file is reread on every render, so a change only needs a reload. Its
+	// directory is watched (editors replace files on save), last so we know whether
+	// anything else there is already being watched
+	if s.cfg.EnvFile != "" {
+		envDir := filepath.Clean(filepath.Dir(s.cfg.EnvFile))
+		watched := false
+		for _, dir := range w.WatchList() {
+			if filepath.Clean(dir) == envDir {
+				watched = true
+				break
+			}
+		}
+		if !watched && dirExists(envDir) {
+			w.Add(envDir)
+			s.envOnlyDir = envDir
+		}
+	}
+
 	go s.watchLoop()
 	return nil
 }
@@ -322,10 +843,30 @@ func (s *DevServer) watchLoop() {
 						addRecursiveWatch(s.watcher, event.Name)
 					}
 				}
+				if s.cfg.EnvFile != "" && filepath.Clean(event.Name) == filepath.Clean(s.cfg.EnvFile) {
+					log.Printf("🌱 Env file changed: %s", event.Name)
+					if s.cfg.Snapshot {
+						s.snapshotFile(event.Name)
+					}
+					s.notifyClients(reloadEvent{File: event.Name, All: true})
+					continue
+				}
+				if s.envOnlyDir != "" && filepath.Dir(filepath.Clean(event.Name)) == s.envOnlyDir {
+					continue
+				}
+				// Stylesheets are swapped in place; templates and data are unaffected
+				if s.isStaticStylesheet(event.Name) {
+					log.Printf("🎨 Stylesheet changed: %s", event.Name)
+					s.notifyClients(reloadEvent{File: event.Name, CSS: true})
+					continue
+				}
 				log.Printf("🔄 File changed: %s", event.Name)
+				if s.cfg.Snapshot {
+					s.snapshotFile(event.Name)
+				}
 				if s.contextMode {
 					// Reload data if a data file changed
-					if strings.HasSuffix(event.Name, ".json") {
+					if isDataFileName(event.Name) {
 						s.loadContextData()
 					}
 					// Re-discover pages if an HTML file was added or removed
@@ -336,7 +877,13 @@ func (s *DevServer) watchLoop() {
 				} else {
 					s.rebuildNavTree()
 				}
-				s.notifyClients()
+				s.clearSampleCache()
+				s.clearIconCache()
+				s.clearTemplateCache()
+				if s.cfg.Preload {
+					s.preloadTemplates()
+				}
+				s.notifyClients(s.reloadEventFor(event))
 			}
 		case err, ok := <-s.watcher.Errors:
 			if !ok {
@@ -347,21 +894,70 @@ func (s *DevServer) watchLoop() {
 	}
 }
 
+// ── Snapshot ────────────────────────────────────────────────────────────────
+
+// snapshotSettleDelay is the pause between reads when waiting for a file to stop changing.
+const snapshotSettleDelay = 50 * time.Millisecond
+
+// snapshotFile reads a changed file until two consecutive reads agree, then swaps
+// it into a fresh copy of the snapshot so in-flight requests keep a consistent set.
+func (s *DevServer) snapshotFile(path string) {
+	var content []byte
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		var next []byte
+		next, err = os.ReadFile(path)
+		if err == nil && content != nil && bytes.Equal(content, next) {
+			break
+		}
+		content = next
+		time.Sleep(snapshotSettleDelay)
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	next := make(map[string][]byte, len(s.snapshot)+1)
+	for k, v := range s.snapshot {
+		next[k] = v
+	}
+	if err != nil {
+		// Removed or unreadable — fall back to disk reads for this path
+		delete(next, path)
+	} else {
+		next[path] = content
+	}
+	s.snapshot = next
+}
+
+// readFile returns the snapshotted content for path when snapshot mode holds one,
+// otherwise it reads from disk.
+func (s *DevServer) readFile(path string) ([]byte, error) {
+	if s.cfg.Snapshot {
+		s.snapshotMu.RLock()
+		content, ok := s.snapshot[path]
+		s.snapshotMu.RUnlock()
+		if ok {
+			return content, nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
 // ── Navigation tree ─────────────────────────────────────────────────────────
 
 func (s *DevServer) rebuildNavTree() error {
-	root, err := buildNavTree(s.cfg.PagesDir, s.cfg.IndexFile)
+	root, err := buildNavTree(s.cfg.PagesDir, s.cfg.IndexFile, s.cfg.indexNames())
 	if err != nil {
 		return err
 	}
 	s.mu.Lock()
 	s.root = root
-	s.site = Site{Pages: root.Children}
+	s.site = Site{Pages: prefixPages(root.Children, s.cfg.Prefix)}
 	s.mu.Unlock()
 	return nil
 }
 
-func buildNavTree(pagesDir, indexFile string) (*Page, error) {
+func buildNavTree(pagesDir, indexFile string, indexNames []string) (*Page, error) {
 	pagesDir = filepath.Clean(pagesDir)
 
 	root := &Page{
@@ -409,7 +1005,7 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 			if strings.HasPrefix(base, "_") {
 				return filepath.SkipDir
 			}
-			ensureDirNode(dirMap, pagesDir, relPath)
+			ensureDirNode(dirMap, pagesDir, relPath, indexNames)
 			return nil
 		}
 
@@ -423,11 +1019,12 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 		}
 
 		nameWithoutExt := strings.TrimSuffix(base, ext)
-		isDynamic := strings.HasPrefix(nameWithoutExt, "_")
+		isIndex := isIndexName(base, indexNames)
+		isDynamic := strings.HasPrefix(nameWithoutExt, "_") && !isIndex
 		dir := filepath.Dir(relPath)
 
 		var urlPath string
-		if nameWithoutExt == "index" {
+		if isIndex {
 			urlPath = "/" + filepath.ToSlash(dir)
 		} else if dir == "." {
 			urlPath = "/" + nameWithoutExt
@@ -439,7 +1036,7 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 			urlPath = "/"
 		}
 
-		title := serveTitleCase(strings.ReplaceAll(strings.ReplaceAll(nameWithoutExt, "-", " "), "_", " "))
+		title := serveTitleCase(strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(nameWithoutExt, "-", " "), "_", " ")))
 
 		page := &Page{
 			Path:     urlPath,
@@ -454,7 +1051,7 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 			applyMeta(page, meta, pageData)
 		}
 
-		if nameWithoutExt == "index" {
+		if isIndex {
 			if existing, ok := dirMap[dir]; ok {
 				existing.File = page.File
 				existing.Title = page.Title
@@ -471,7 +1068,7 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 		if parentDir == "" {
 			parentDir = "."
 		}
-		parent := ensureDirNode(dirMap, pagesDir, parentDir)
+		parent := ensureDirNode(dirMap, pagesDir, parentDir, indexNames)
 		parent.Children = append(parent.Children, page)
 
 		return nil
@@ -481,7 +1078,7 @@ func buildNavTree(pagesDir, indexFile string) (*Page, error) {
 	return root, nil
 }
 
-func ensureDirNode(dirMap map[string]*Page, pagesDir, relDir string) *Page {
+func ensureDirNode(dirMap map[string]*Page, pagesDir, relDir string, indexNames []string) *Page {
 	if relDir == "." {
 		return dirMap["."]
 	}
@@ -493,10 +1090,13 @@ func ensureDirNode(dirMap map[string]*Page, pagesDir, relDir string) *Page {
 	title := serveTitleCase(strings.ReplaceAll(base, "-", " "))
 	urlPath := "/" + filepath.ToSlash(relDir)
 
-	indexFile := filepath.Join(pagesDir, relDir, "index.html")
 	resolvedFile := ""
-	if fileExistsServe(indexFile) {
-		resolvedFile = indexFile
+	for _, name := range indexNames {
+		indexFile := filepath.Join(pagesDir, relDir, name)
+		if fileExistsServe(indexFile) {
+			resolvedFile = indexFile
+			break
+		}
 	}
 
 	node := &Page{
@@ -512,7 +1112,7 @@ func ensureDirNode(dirMap map[string]*Page, pagesDir, relDir string) *Page {
 	if parentDir == "" {
 		parentDir = "."
 	}
-	parent := ensureDirNode(dirMap, pagesDir, parentDir)
+	parent := ensureDirNode(dirMap, pagesDir, parentDir, indexNames)
 	parent.Children = append(parent.Children, node)
 	return node
 }
@@ -591,27 +1191,45 @@ func findPageRecursive(node *Page, urlPath string) (*Page, string) {
 
 // ── Context mode page discovery ─────────────────────────────────────────────
 
-// isContentPage checks whether template text contains a {{define "content"}} block,
-// which identifies it as a page template (as opposed to a partial, modal, or layout).
-func isContentPage(text string) bool {
-	return strings.Contains(text, `{{define "content"}}`) ||
-		strings.Contains(text, `{{ define "content" }}`) ||
-		strings.Contains(text, `{{- define "content" -}}`)
+// defineActionPattern matches {{define "name"}} actions written with the given delimiters
+func defineActionPattern(left, right string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(left) + `-?\s*define\s+"([^"]+)"\s*-?` + regexp.QuoteMeta(right))
+}
+
+// isContentPage checks whether template text defines one of the layout's page blocks
+// (by default {{define "content"}}), which identifies it as a page template (as opposed
+// to a partial, modal, or layout). A page may fill any subset of the blocks, e.g. only
+// "sidebar" in a multi-slot layout.
+func (s *DevServer) isContentPage(text string) bool {
+	for _, m := range s.defineRe.FindAllStringSubmatch(text, -1) {
+		for _, block := range s.cfg.pageBlocks() {
+			if m[1] == block {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // classifyContextFiles separates the context files into shared templates (layouts/partials)
-// and page templates. A file is considered a "page" if it contains {{define "content"}} or
-// similar block definitions. Files that don't define content blocks are treated as shared
+// and page templates. A file is considered a "page" if it defines one of the page blocks
+// (pageBlocks, default "content"). Files that don't define page blocks are treated as shared
 // (layouts, partials) that get loaded for every page render.
+//
+// The entry file is always placed first in sharedFiles. Parse order matters for
+// {{block}} defaults: a later {{define}} of the same name replaces an earlier one, so
+// the layout must be parsed before partials and pages or it would clobber their overrides.
 func (s *DevServer) classifyContextFiles() {
 	s.sharedFiles = nil
 
 	entryBase := filepath.Base(s.cfg.EntryFile)
+	var entryFiles []string
+
 	for _, file := range s.cfg.ContextFiles {
 		base := filepath.Base(file)
 		// The entry file (e.g., base.html) is always shared — it's the layout
 		if base == entryBase || file == s.cfg.EntryFile {
-			s.sharedFiles = append(s.sharedFiles, file)
+			entryFiles = append(entryFiles, file)
 			log.Printf("  📄 Shared (entry): %s", base)
 			continue
 		}
@@ -625,15 +1243,43 @@ func (s *DevServer) classifyContextFiles() {
 		}
 
 		text := string(content)
-		// Files that define "content" are page templates — they'll be swapped per page
-		// Files that DON'T define content are shared (partials, helpers, etc.)
-		if !isContentPage(text) {
+		// Files that define a page block are page templates — they'll be swapped per page
+		// Files that DON'T are shared (partials, helpers, etc.)
+		isPage, forced := s.forcedClassification(file)
+		if !forced {
+			isPage = s.isContentPage(text)
+		}
+		if !isPage {
 			s.sharedFiles = append(s.sharedFiles, file)
 			log.Printf("  📄 Shared (partial): %s", base)
 		} else {
 			log.Printf("  📄 Page (content): %s", base)
 		}
 	}
+
+	s.sharedFiles = append(entryFiles, s.sharedFiles...)
+}
+
+// forcedClassification reports whether file is listed in forcePages or forceShared,
+// and if so whether it is forced to be a page. forcePages wins when a file is in both.
+func (s *DevServer) forcedClassification(file string) (isPage, forced bool) {
+	matches := func(list []string) bool {
+		rel, _ := filepath.Rel(filepath.Dir(s.cfg.EntryFile), file)
+		for _, entry := range list {
+			entry = filepath.Clean(filepath.FromSlash(entry))
+			if entry == file || entry == rel || entry == filepath.Base(file) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(s.cfg.ForcePages) {
+		return true, true
+	}
+	if matches(s.cfg.ForceShared) {
+		return false, true
+	}
+	return false, false
 }
 
 // discoverPages scans the directories containing the context files to find all navigable
@@ -693,6 +1339,14 @@ func (s *DevServer) discoverPages() {
 		}
 	}
 
+	// Strict context: the render context is the whole template set — no walks at all
+	if s.cfg.StrictContext {
+		s.addContextFilePages(pagesRoot)
+		log.Printf("  🔒 Strict context: skipped page and shared-template discovery")
+		log.Printf("  ✅ Discovered %d navigable pages (from context only)", len(s.contextPages))
+		return
+	}
+
 	// If still no pages root, only scan the specific directories containing context files.
 	// Do NOT fall back to the entire entryDir — that would pick up unrelated HTML files.
 	if pagesRoot == "" {
@@ -721,7 +1375,8 @@ func (s *DevServer) discoverPages() {
 			return nil
 		}
 		base := filepath.Base(filePath)
-		if strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+		isIndex := isIndexName(base, s.cfg.indexNames())
+		if strings.HasPrefix(base, ".") || (strings.HasPrefix(base, "_") && !isIndex) {
 			return nil
 		}
 		// Skip already-known files
@@ -735,50 +1390,25 @@ func (s *DevServer) discoverPages() {
 			return nil
 		}
 		text := string(content)
-		if !isContentPage(text) {
+		isPage, forced := s.forcedClassification(filePath)
+		if forced && !isPage {
+			// Forced shared files under the pages root are loaded for every page
+			s.sharedFiles = append(s.sharedFiles, filePath)
+			knownFiles[filePath] = true
+			log.Printf("  📄 Shared (forced): %s", base)
 			return nil
 		}
-
-		// Build URL path from relative path
-		relPath, relErr := filepath.Rel(pagesRoot, filePath)
-		if relErr != nil {
+		if !forced && !s.isContentPage(text) {
 			return nil
 		}
 
-		nameWithoutExt := strings.TrimSuffix(filepath.Base(relPath), ".html")
-		dir := filepath.Dir(relPath)
-
-		var urlPath string
-		if nameWithoutExt == "index" {
-			if dir == "." {
-				urlPath = "/"
-			} else {
-				urlPath = "/" + filepath.ToSlash(dir)
-			}
-		} else if dir == "." {
-			urlPath = "/" + nameWithoutExt
-		} else {
-			urlPath = "/" + filepath.ToSlash(dir) + "/" + nameWithoutExt
-		}
-		urlPath = strings.TrimSuffix(urlPath, "/")
-		if urlPath == "" {
-			urlPath = "/"
-		}
-
-		title := serveTitleCase(strings.ReplaceAll(strings.ReplaceAll(nameWithoutExt, "-", " "), "_", " "))
-
-		page := &ContextPage{
-			URLPath:  urlPath,
-			FilePath: filePath,
-			Title:    title,
+		page := s.newContextPage(pagesRoot, filePath)
+		if page == nil {
+			return nil
 		}
-
-		// Try to find a linked data file for this page
-		page.DataFile = s.findDataFileForPage(filePath)
-
 		s.contextPages = append(s.contextPages, page)
 		knownFiles[filePath] = true
-		log.Printf("  📑 Page: %s → %s", urlPath, base)
+		log.Printf("  📑 Page: %s → %s", page.URLPath, base)
 		return nil
 	})
 
@@ -833,7 +1463,11 @@ func (s *DevServer) discoverPages() {
 				if readErr != nil {
 					return nil
 				}
-				if isContentPage(string(content)) {
+				isPage, forced := s.forcedClassification(filePath)
+				if !forced {
+					isPage = s.isContentPage(string(content))
+				}
+				if isPage {
 					return nil // Skip page templates
 				}
 
@@ -853,26 +1487,95 @@ func (s *DevServer) discoverPages() {
 	}
 }
 
-// findDataFileForPage looks in .vscode/template-data/ for a data file that matches the given page.
-func (s *DevServer) findDataFileForPage(pageFile string) string {
-	if s.cfg.DataDir == "" || !dirExists(s.cfg.DataDir) {
-		return ""
+// newContextPage builds the page for a template file, with its URL path relative to
+// pagesRoot (an index file maps to its directory) and a title from its file name.
+// It returns nil when the file isn't under pagesRoot.
+func (s *DevServer) newContextPage(pagesRoot, filePath string) *ContextPage {
+	relPath, err := filepath.Rel(pagesRoot, filePath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return nil
 	}
 
-	entries, err := os.ReadDir(s.cfg.DataDir)
-	if err != nil {
-		return ""
+	nameWithoutExt := strings.TrimSuffix(filepath.Base(relPath), ".html")
+	dir := filepath.Dir(relPath)
+
+	var urlPath string
+	if isIndexName(filepath.Base(filePath), s.cfg.indexNames()) {
+		if dir == "." {
+			urlPath = "/"
+		} else {
+			urlPath = "/" + filepath.ToSlash(dir)
+		}
+	} else if dir == "." {
+		urlPath = "/" + nameWithoutExt
+	} else {
+		urlPath = "/" + filepath.ToSlash(dir) + "/" + nameWithoutExt
+	}
+	urlPath = strings.TrimSuffix(urlPath, "/")
+	if urlPath == "" {
+		urlPath = "/"
 	}
 
-	pageBase := filepath.Base(pageFile)
+	title := serveTitleCase(strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(nameWithoutExt, "-", " "), "_", " ")))
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
-			continue
-		}
+	page := &ContextPage{
+		URLPath:  urlPath,
+		FilePath: filePath,
+		Title:    title,
+	}
 
-		dataPath := filepath.Join(s.cfg.DataDir, entry.Name())
-		raw, err := os.ReadFile(dataPath)
+	// Try to find a linked data file for this page
+	page.DataFile = s.findDataFileForPage(filePath)
+	return page
+}
+
+// addContextFilePages registers the context files classified as pages, and nothing
+// else, for StrictContext. URLs are relative to pagesRoot for files under it and to
+// the file's own directory otherwise.
+func (s *DevServer) addContextFilePages(pagesRoot string) {
+	shared := make(map[string]bool)
+	for _, file := range s.sharedFiles {
+		shared[file] = true
+	}
+	for _, file := range s.cfg.ContextFiles {
+		if shared[file] || file == s.cfg.EntryFile || !fileExistsServe(file) {
+			continue
+		}
+		var page *ContextPage
+		if pagesRoot != "" {
+			page = s.newContextPage(pagesRoot, file)
+		}
+		if page == nil {
+			page = s.newContextPage(filepath.Dir(file), file)
+		}
+		s.contextPages = append(s.contextPages, page)
+		log.Printf("  📑 Page: %s → %s", page.URLPath, filepath.Base(file))
+	}
+	sort.Slice(s.contextPages, func(i, j int) bool {
+		return s.contextPages[i].URLPath < s.contextPages[j].URLPath
+	})
+}
+
+// findDataFileForPage looks in .vscode/template-data/ for a data file that matches the given page.
+func (s *DevServer) findDataFileForPage(pageFile string) string {
+	if s.cfg.DataDir == "" || !dirExists(s.cfg.DataDir) {
+		return ""
+	}
+
+	entries, err := os.ReadDir(s.cfg.DataDir)
+	if err != nil {
+		return ""
+	}
+
+	pageBase := filepath.Base(pageFile)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isDataFileName(entry.Name()) {
+			continue
+		}
+
+		dataPath := filepath.Join(s.cfg.DataDir, entry.Name())
+		raw, err := readDataFile(dataPath)
 		if err != nil {
 			continue
 		}
@@ -902,7 +1605,7 @@ func (s *DevServer) findDataFileForPage(pageFile string) string {
 		}
 
 		// Filename-based match
-		nameWithoutExt := strings.TrimSuffix(entry.Name(), ".json")
+		nameWithoutExt := trimDataExt(entry.Name())
 		if nameWithoutExt == pageBase || strings.HasSuffix(nameWithoutExt, "--"+pageBase) {
 			return dataPath
 		}
@@ -931,6 +1634,38 @@ func (s *DevServer) findContextPage(urlPath string) *ContextPage {
 
 // ── Data loading ────────────────────────────────────────────────────────────
 
+// isDataFileName reports whether name is a JSON data file, plain or gzip-compressed.
+func isDataFileName(name string) bool {
+	return strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz")
+}
+
+// trimDataExt strips the .json or .json.gz extension from a data filename.
+func trimDataExt(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".json")
+}
+
+// readDataFile reads a JSON data file, transparently decompressing .json.gz files.
+func readDataFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decompressData(path, raw)
+}
+
+// decompressData gunzips raw when path has a .gz extension and returns it unchanged otherwise.
+func decompressData(path string, raw []byte) ([]byte, error) {
+	if !strings.HasSuffix(path, ".gz") {
+		return raw, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", filepath.Base(path), err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
 // loadContextData loads data from the extension's linked data file (.vscode/template-data/).
 // This is the unified system — the same data file the extension uses for its preview.
 func (s *DevServer) loadContextData() {
@@ -941,7 +1676,10 @@ func (s *DevServer) loadContextData() {
 
 	// Primary: use the explicitly linked data file
 	if s.cfg.DataFile != "" && fileExistsServe(s.cfg.DataFile) {
-		raw, err := os.ReadFile(s.cfg.DataFile)
+		raw, err := s.readFile(s.cfg.DataFile)
+		if err == nil {
+			raw, err = decompressData(s.cfg.DataFile, raw)
+		}
 		if err != nil {
 			log.Printf("⚠️  Failed to read data file %s: %v", s.cfg.DataFile, err)
 			return
@@ -962,12 +1700,12 @@ func (s *DevServer) loadContextData() {
 		}
 		entryBase := filepath.Base(s.cfg.EntryFile)
 		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			if entry.IsDir() || !isDataFileName(entry.Name()) {
 				continue
 			}
 			// Check if this data file's _templateContext.entryFile matches our entry
 			dataPath := filepath.Join(s.cfg.DataDir, entry.Name())
-			raw, err := os.ReadFile(dataPath)
+			raw, err := readDataFile(dataPath)
 			if err != nil {
 				continue
 			}
@@ -986,7 +1724,7 @@ func (s *DevServer) loadContextData() {
 				}
 			}
 			// Fallback: filename-based match (sanitized path naming convention)
-			nameWithoutExt := strings.TrimSuffix(entry.Name(), ".json")
+			nameWithoutExt := trimDataExt(entry.Name())
 			if nameWithoutExt == entryBase || strings.HasSuffix(nameWithoutExt, "--"+entryBase) {
 				s.contextData = data
 				log.Printf("📊 Auto-discovered data file by name: %s", entry.Name())
@@ -1059,15 +1797,73 @@ func loadSlugData(templatePath, slug string) map[string]any {
 
 // ── HTTP handlers ───────────────────────────────────────────────────────────
 
+// fileServer serves files under root, without directory listings when
+// DisableDirListing is set
+func (s *DevServer) fileServer(root string) http.Handler {
+	var fsys http.FileSystem = http.Dir(root)
+	if s.cfg.DisableDirListing {
+		fsys = noListingFS{fsys}
+	}
+	return http.FileServer(fsys)
+}
+
+// noListingFS hides directories that have no index.html, so http.FileServer answers
+// 404 where it would otherwise list them. Directories with an index still serve it.
+type noListingFS struct {
+	http.FileSystem
+}
+
+func (fsys noListingFS) Open(name string) (http.File, error) {
+	f, err := fsys.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || !info.IsDir() {
+		return f, err
+	}
+	index, err := fsys.FileSystem.Open(path.Join(name, "index.html"))
+	if err != nil {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	index.Close()
+	return f, nil
+}
+
 func (s *DevServer) handlePage(w http.ResponseWriter, r *http.Request) {
 	urlPath := r.URL.Path
 
+	rec := newStatusRecorder(w)
+	w = rec
+	defer s.logRequestDone(r, rec)
+
+	if s.serveRootTextFile(w, r) {
+		return
+	}
+
+	if s.serveCSVTemplate(w, r) {
+		return
+	}
+
 	if urlPath == "/favicon.ico" {
 		http.NotFound(w, r)
 		return
 	}
 
-	log.Printf("📄 %s %s", r.Method, urlPath)
+	s.logRequest(w, "")
+
+	if s.redirectTrailingSlash(w, r) {
+		return
+	}
+
+	if s.cfg.NegotiateJSON {
+		w.Header().Add("Vary", "Accept")
+		if prefersJSON(r.Header.Get("Accept")) {
+			s.handlePageJSON(w, r, urlPath)
+			return
+		}
+	}
 
 	if s.contextMode {
 		s.handleContextPage(w, r)
@@ -1077,12 +1873,246 @@ func (s *DevServer) handlePage(w http.ResponseWriter, r *http.Request) {
 	s.handleConventionPage(w, r, urlPath)
 }
 
+// handlePageJSON responds with the data a render of urlPath would receive, including
+// ?set= overrides, for clients that ask for JSON via the Accept header.
+func (s *DevServer) handlePageJSON(w http.ResponseWriter, r *http.Request, urlPath string) {
+	if entryFile, _ := s.templateFilesForPath(urlPath); entryFile == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// ?set= overrides land where the page handlers put them: the top-level map in
+	// context mode, .Data in convention mode
+	data := s.pageRenderData(urlPath)
+	target, ok := data.(map[string]any)
+	if rd, isRenderData := data.(RenderData); isRenderData {
+		target, ok = rd.Data, true
+	}
+	if ok {
+		if err := applyQueryOverrides(target, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
+
+// prefersJSON reports whether an Accept header ranks application/json above text/html.
+// Each type gets the q-value of its most specific matching range; ties go to HTML, so
+// browsers and "*/*" clients get the rendered page.
+func prefersJSON(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	quality := func(mediaType string) float64 {
+		best, bestSpecificity := 0.0, -1
+		major := strings.SplitN(mediaType, "/", 2)[0]
+		for _, part := range strings.Split(accept, ",") {
+			fields := strings.Split(part, ";")
+			rng := strings.ToLower(strings.TrimSpace(fields[0]))
+			specificity := -1
+			switch rng {
+			case mediaType:
+				specificity = 2
+			case major + "/*":
+				specificity = 1
+			case "*/*":
+				specificity = 0
+			}
+			if specificity <= bestSpecificity {
+				continue
+			}
+			q := 1.0
+			for _, param := range fields[1:] {
+				if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(k) == "q" {
+					if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+			best, bestSpecificity = q, specificity
+		}
+		return best
+	}
+	return quality("application/json") > quality("text/html")
+}
+
+// serveRootTextFile serves root-level text files such as /robots.txt or /humans.txt
+// straight from the content root, like a deployed site would, instead of routing them
+// through the template handler. It reports whether a file was written.
+func (s *DevServer) serveRootTextFile(w http.ResponseWriter, r *http.Request) bool {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" || strings.Contains(name, "/") || filepath.Ext(name) != ".txt" {
+		return false
+	}
+
+	var roots []string
+	if s.contextMode {
+		roots = []string{s.cfg.ContentRoot, filepath.Dir(s.cfg.EntryFile)}
+	} else {
+		roots = []string{s.cfg.StaticDir, s.cfg.PagesDir}
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		path := filepath.Join(root, name)
+		if !fileExistsServe(path) {
+			continue
+		}
+		content, err := s.readFile(path)
+		if err != nil {
+			continue
+		}
+		s.logRequest(w, "text file")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(content)
+		return true
+	}
+	return false
+}
+
+// serveCSVTemplate renders a .csv template for a request such as /reports/sales.csv.
+// The file is looked up under the content root (or entry dir) in context mode and the
+// pages dir in convention mode, executed with text/template so nothing is HTML-escaped,
+// and served as text/csv with the data the matching page (/reports/sales) would get.
+// It reports whether a response was written.
+func (s *DevServer) serveCSVTemplate(w http.ResponseWriter, r *http.Request) bool {
+	if !strings.EqualFold(filepath.Ext(r.URL.Path), ".csv") {
+		return false
+	}
+	name := filepath.FromSlash(path.Clean("/" + strings.TrimPrefix(r.URL.Path, "/")))
+
+	var roots []string
+	if s.contextMode {
+		roots = []string{s.cfg.ContentRoot, filepath.Dir(s.cfg.EntryFile)}
+	} else {
+		roots = []string{s.cfg.PagesDir}
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		file := filepath.Join(root, name)
+		if !fileExistsServe(file) {
+			continue
+		}
+		s.logRequest(w, "csv")
+		content, err := s.readFile(file)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read %s: %v", filepath.Base(file), err), http.StatusInternalServerError)
+			return true
+		}
+		tmpl, err := texttemplate.New(filepath.Base(file)).Delims(s.cfg.delims()).Funcs(texttemplate.FuncMap(s.funcMap())).Parse(string(content))
+		if err != nil {
+			log.Printf("❌ Template parse error in %s: %v", file, err)
+			http.Error(w, fmt.Sprintf("Template error in %s: %v", filepath.Base(file), err), http.StatusInternalServerError)
+			return true
+		}
+		var buf bytes.Buffer
+		pagePath := strings.TrimSuffix(r.URL.Path, filepath.Ext(r.URL.Path))
+		if err := tmpl.Execute(&buf, s.pageRenderData(pagePath)); err != nil {
+			log.Printf("❌ Render error: %v", err)
+			http.Error(w, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
+			return true
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filepath.Base(file)))
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(buf.Bytes())
+		return true
+	}
+	return false
+}
+
+// handleWellKnown serves /.well-known/* from the content root or entry dir in context
+// mode, or the static or pages dir in convention mode. Page discovery and the static
+// handlers skip dot-prefixed paths, so these files need their own route.
+func (s *DevServer) handleWellKnown(w http.ResponseWriter, r *http.Request) {
+	rec := newStatusRecorder(w)
+	w = rec
+	defer s.logRequestDone(r, rec)
+
+	name := filepath.FromSlash(path.Clean(r.URL.Path))
+
+	var roots []string
+	if s.contextMode {
+		roots = []string{s.cfg.ContentRoot, filepath.Dir(s.cfg.EntryFile)}
+	} else {
+		roots = []string{s.cfg.StaticDir, s.cfg.PagesDir}
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		file := filepath.Join(root, name)
+		if !fileExistsServe(file) {
+			continue
+		}
+		s.logRequest(w, "well-known")
+		// apple-app-site-association is extensionless JSON and would be sniffed as text/plain
+		if filepath.Base(file) == "apple-app-site-association" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		http.ServeFile(w, r, file)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// redirectTrailingSlash issues a 301 to the canonical form of the URL according to the
+// trailingSlash setting. It reports whether a redirect was written.
+func (s *DevServer) redirectTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
+	urlPath := r.URL.Path
+	if urlPath == "/" {
+		return false
+	}
+
+	var target string
+	switch s.cfg.TrailingSlash {
+	case "never":
+		if strings.HasSuffix(urlPath, "/") {
+			target = strings.TrimRight(urlPath, "/")
+		}
+	case "always":
+		// Leave file-like paths (e.g., /feed.xml) alone
+		if !strings.HasSuffix(urlPath, "/") && filepath.Ext(urlPath) == "" {
+			target = urlPath + "/"
+		}
+	}
+	if target == "" {
+		return false
+	}
+
+	target = s.withPrefix(target)
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
+
 // handleContextPage renders using the extension's render context (shared files + discovered pages).
 // Navigation works by swapping in the appropriate page template while keeping the shared
 // templates (layout, partials) from the context.
 func (s *DevServer) handleContextPage(w http.ResponseWriter, r *http.Request) {
 	urlPath := r.URL.Path
+	nonce := s.newResponseNonce()
+	output, found, err := s.renderContextPage(urlPath, nonce, func(data map[string]any) error {
+		return applyQueryOverrides(data, r)
+	})
+	s.writePage(w, r, urlPath, output, nonce, found, err)
+}
 
+// renderContextPage renders urlPath in context mode and returns the output before live
+// reload is injected. found is false when no page matches. override, when set, adjusts
+// the data before execution (?set= and query parameters for requests).
+func (s *DevServer) renderContextPage(urlPath, nonce string, override func(map[string]any) error) (output string, found bool, err error) {
 	// Determine which page file to render
 	var pageFile string
 	var pageData map[string]any
@@ -1105,6 +2135,7 @@ func (s *DevServer) handleContextPage(w http.ResponseWriter, r *http.Request) {
 				// Try to find a root/index page
 				for _, p := range s.contextPages {
 					if p.URLPath == "/" {
+						ctxPage = p
 						pageFile = p.FilePath
 						if p.DataFile != "" {
 							pageData = loadJSONFile(p.DataFile)
@@ -1114,6 +2145,7 @@ func (s *DevServer) handleContextPage(w http.ResponseWriter, r *http.Request) {
 				}
 				// If no root page, use the first page
 				if pageFile == "" {
+					ctxPage = s.contextPages[0]
 					pageFile = s.contextPages[0].FilePath
 					if s.contextPages[0].DataFile != "" {
 						pageData = loadJSONFile(s.contextPages[0].DataFile)
@@ -1124,49 +2156,85 @@ func (s *DevServer) handleContextPage(w http.ResponseWriter, r *http.Request) {
 				pageFile = ""
 			}
 		} else {
-			http.NotFound(w, r)
-			return
+			return "", false, nil
 		}
 	}
 
 	// Build template set: shared files + the page file
-	tmpl := template.New("").Funcs(serveFuncMap())
+	tmpl, err := s.cachedTemplates(pageFile, s.loadContextTemplates)
+	if err != nil {
+		return "", true, &renderError{status: http.StatusInternalServerError, title: "Template error", err: err}
+	}
 
-	// Load all shared files (layout, partials) — these are always included
-	for _, file := range s.sharedFiles {
-		if !fileExistsServe(file) {
-			log.Printf("⚠️  Shared file not found: %s", file)
-			continue
-		}
-		content, err := os.ReadFile(file)
-		if err != nil {
-			log.Printf("⚠️  Failed to read shared file %s: %v", file, err)
-			continue
-		}
-		_, err = tmpl.New(filepath.Base(file)).Parse(string(content))
-		if err != nil {
-			log.Printf("❌ Template parse error in %s: %v", file, err)
-			http.Error(w, fmt.Sprintf("Template error in %s: %v", filepath.Base(file), err), http.StatusInternalServerError)
-			return
+	// Build the render data — merge context data with per-page data
+	data := s.buildContextRenderData(urlPath, ctxPage, pageData)
+	if override != nil {
+		if err := override(data); err != nil {
+			return "", true, &renderError{status: http.StatusBadRequest, err: err}
 		}
 	}
 
-	// Load the page template (the one with {{define "content"}})
-	if pageFile != "" && fileExistsServe(pageFile) {
-		content, err := os.ReadFile(pageFile)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to read page: %v", err), http.StatusInternalServerError)
-			return
+	// Expose the CSP nonce so page scripts can opt in to the same policy
+	if nonce != "" {
+		data["_cspNonce"] = nonce
+	}
+
+	// Render the entry template (the layout)
+	entryName := filepath.Base(s.cfg.EntryFile)
+	var buf bytes.Buffer
+	if err := s.executeTemplate(&buf, tmpl, entryName, data); err != nil {
+		return "", true, &renderError{status: http.StatusInternalServerError, title: "Render error", err: err}
+	}
+	return buf.String(), true, nil
+}
+
+// renderError is a failed page render with the status and overlay title it's reported
+// with. A 400 (a bad ?set= override) has no title and is sent as plain text.
+type renderError struct {
+	status int
+	title  string
+	err    error
+}
+
+func (e *renderError) Error() string {
+	if e.title == "" {
+		return e.err.Error()
+	}
+	return e.title + ": " + e.err.Error()
+}
+
+func (e *renderError) Unwrap() error { return e.err }
+
+// writePage sends the result of renderContextPage or renderConventionPage: the page
+// with live reload and CSP headers, a 404 when nothing matched, or the error overlay.
+func (s *DevServer) writePage(w http.ResponseWriter, r *http.Request, urlPath, output, nonce string, found bool, err error) {
+	if !found {
+		s.notFound(w, r, urlPath)
+		return
+	}
+	if err != nil {
+		re, ok := err.(*renderError)
+		if !ok {
+			re = &renderError{status: http.StatusInternalServerError, title: "Render error", err: err}
 		}
-		_, err = tmpl.New(filepath.Base(pageFile)).Parse(string(content))
-		if err != nil {
-			log.Printf("❌ Template parse error in %s: %v", pageFile, err)
-			http.Error(w, fmt.Sprintf("Template error in %s: %v", filepath.Base(pageFile), err), http.StatusInternalServerError)
+		if re.title == "" {
+			http.Error(w, re.err.Error(), re.status)
 			return
 		}
+		log.Printf("❌ %s: %v", re.title, re.err)
+		s.writeErrorOverlay(w, re.status, re.title, re.err, urlPath)
+		return
 	}
 
-	// Build the render data — merge context data with per-page data
+	output = s.injectLiveReload(output, nonce)
+	s.setCSPHeader(w, nonce)
+	w.Header().Set("Content-Type", s.pageContentType(output))
+	fmt.Fprint(w, output)
+}
+
+// buildContextRenderData merges the linked context data with per-page data and adds
+// navigation info for rendering urlPath in context mode.
+func (s *DevServer) buildContextRenderData(urlPath string, ctxPage *ContextPage, pageData map[string]any) map[string]any {
 	s.mu.RLock()
 	data := make(map[string]any)
 	for k, v := range s.contextData {
@@ -1187,21 +2255,20 @@ func (s *DevServer) handleContextPage(w http.ResponseWriter, r *http.Request) {
 
 	// Add navigation info so templates can build menus
 	data["_pages"] = s.buildContextNavData(urlPath)
-	data["_currentPath"] = urlPath
+	data["_currentPath"] = s.withPrefix(urlPath)
+	data["_prefix"] = s.cfg.Prefix
 
-	// Render the entry template (the layout)
-	entryName := filepath.Base(s.cfg.EntryFile)
-	var buf bytes.Buffer
-	err := tmpl.ExecuteTemplate(&buf, entryName, data)
-	if err != nil {
-		log.Printf("❌ Render error: %v", err)
-		http.Error(w, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
-		return
+	// Mirror convention mode's RenderData.Page so the same layout works in both modes
+	if _, exists := data["Page"]; !exists {
+		page := Page{Path: s.withPrefix(urlPath), Title: "Home"}
+		if ctxPage != nil {
+			page.File = ctxPage.FilePath
+			page.Title = ctxPage.Title
+		}
+		data["Page"] = page
 	}
 
-	output := s.injectLiveReload(buf.String())
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, output)
+	return data
 }
 
 // buildContextNavData creates navigation data from discovered pages.
@@ -1212,7 +2279,7 @@ func (s *DevServer) buildContextNavData(currentPath string) []map[string]any {
 	var nav []map[string]any
 	for _, p := range s.contextPages {
 		nav = append(nav, map[string]any{
-			"Path":   p.URLPath,
+			"Path":   s.withPrefix(p.URLPath),
 			"Title":  p.Title,
 			"Active": p.URLPath == currentPath,
 		})
@@ -1220,9 +2287,42 @@ func (s *DevServer) buildContextNavData(currentPath string) []map[string]any {
 	return nav
 }
 
+// queryDataPrefix marks a query parameter as a data override even when the key is not
+// in the data yet (?d_beta=true sets .beta)
+const queryDataPrefix = "d_"
+
+// applyQueryOverrides overlays plain query parameters onto data, then applies each
+// ?set=key=value parameter. A plain parameter only applies when its key is already a
+// top-level data key or carries the d_ prefix, so unrelated params (and the _pages/
+// _currentPath builtins) are left alone. Values are typed like ?set= values.
+func applyQueryOverrides(data map[string]any, r *http.Request) error {
+	query := r.URL.Query()
+	for param, values := range query {
+		if param == "set" || len(values) == 0 {
+			continue
+		}
+		key := param
+		if stripped, ok := strings.CutPrefix(param, queryDataPrefix); ok {
+			key = stripped
+		} else if _, exists := data[key]; !exists {
+			continue
+		}
+		if key == "" || strings.HasPrefix(key, "_") {
+			continue
+		}
+		data[key] = parseOverrideValue(values[0])
+	}
+	for _, spec := range query["set"] {
+		if err := applyDataOverride(data, spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // loadJSONFile reads and parses a JSON file, returning nil on error.
 func loadJSONFile(filePath string) map[string]any {
-	raw, err := os.ReadFile(filePath)
+	raw, err := readDataFile(filePath)
 	if err != nil {
 		return nil
 	}
@@ -1235,6 +2335,17 @@ func loadJSONFile(filePath string) map[string]any {
 
 // handleConventionPage renders using the convention-based directory structure.
 func (s *DevServer) handleConventionPage(w http.ResponseWriter, r *http.Request, urlPath string) {
+	nonce := s.newResponseNonce()
+	output, found, err := s.renderConventionPage(urlPath, nonce, func(data map[string]any) error {
+		return applyQueryOverrides(data, r)
+	})
+	s.writePage(w, r, urlPath, output, nonce, found, err)
+}
+
+// renderConventionPage renders urlPath from the pages tree, through the layout when
+// there is one, and returns the output before live reload is injected. found is false
+// when no template matches. override, when set, adjusts .Data before execution.
+func (s *DevServer) renderConventionPage(urlPath, nonce string, override func(map[string]any) error) (output string, found bool, err error) {
 	s.mu.RLock()
 	root := s.root
 	site := s.site
@@ -1250,20 +2361,18 @@ func (s *DevServer) handleConventionPage(w http.ResponseWriter, r *http.Request,
 	}
 
 	if templateFile == "" || !fileExistsServe(templateFile) {
-		http.NotFound(w, r)
-		return
+		return "", false, nil
 	}
 
-	// Load templates fresh (dev mode)
-	t, err := s.loadTemplates(templateFile)
+	// Load templates fresh (dev mode), or from the cache when it is enabled
+	t, err := s.cachedTemplates(templateFile, s.loadTemplates)
 	if err != nil {
-		log.Printf("❌ Template error: %v", err)
-		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
-		return
+		return "", true, &renderError{status: http.StatusInternalServerError, title: "Template error", err: err}
 	}
 
 	// Build render data
 	rd := s.buildRenderData(page, site, urlPath, slug, templateFile)
+	rd.Nonce = nonce
 
 	// Load slug-specific data
 	if slug != "" {
@@ -1278,42 +2387,160 @@ func (s *DevServer) handleConventionPage(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
+	// ?set= overrides land in .Data, like per-page data does
+	if override != nil {
+		if err := override(rd.Data); err != nil {
+			return "", true, &renderError{status: http.StatusBadRequest, err: err}
+		}
+	}
+
 	var buf bytes.Buffer
 	layoutName := s.resolveLayoutName()
 
 	if layoutName != "" {
-		err = t.ExecuteTemplate(&buf, layoutName, rd)
+		err = s.executeTemplate(&buf, t, layoutName, rd)
 		if err != nil {
 			log.Printf("⚠️  Layout %q failed, rendering page directly: %v", layoutName, err)
 			buf.Reset()
-			err = t.Execute(&buf, rd)
+			err = s.executeTemplate(&buf, t, "", rd)
 		}
 	} else {
-		err = t.Execute(&buf, rd)
+		err = s.executeTemplate(&buf, t, "", rd)
 	}
 
 	if err != nil {
-		log.Printf("❌ Render error: %v", err)
-		http.Error(w, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
-		return
+		return "", true, &renderError{status: http.StatusInternalServerError, title: "Render error", err: err}
 	}
-
-	output := s.injectLiveReload(buf.String())
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	fmt.Fprint(w, output)
+	return buf.String(), true, nil
 }
 
-func (s *DevServer) resolveLayoutName() string {
-	if !dirExists(s.cfg.LayoutsDir) {
-		return ""
+// notFoundTitle is the Page.Title the 404 template renders with
+const notFoundTitle = "Not Found"
+
+// notFound responds 404 with the configured NotFoundFile, rendered like a page so the
+// layout and nav are available. Any problem with it falls back to the plain 404, so a
+// broken 404 template never hides which URL was missing.
+func (s *DevServer) notFound(w http.ResponseWriter, r *http.Request, urlPath string) {
+	if s.cfg.NotFoundFile == "" || !fileExistsServe(s.cfg.NotFoundFile) {
+		http.NotFound(w, r)
+		return
 	}
-	if s.cfg.LayoutFile != "" {
-		layoutPath := filepath.Join(s.cfg.LayoutsDir, s.cfg.LayoutFile)
-		if fileExistsServe(layoutPath) {
-			return s.cfg.LayoutFile
-		}
+
+	nonce := s.newResponseNonce()
+	var buf bytes.Buffer
+	var err error
+	if s.contextMode {
+		err = s.renderContextNotFound(&buf, urlPath, nonce)
+	} else {
+		err = s.renderConventionNotFound(&buf, urlPath, nonce)
 	}
-	entries, err := os.ReadDir(s.cfg.LayoutsDir)
+	if err != nil {
+		log.Printf("⚠️  404 template %s failed, sending plain 404: %v", s.cfg.NotFoundFile, err)
+		http.NotFound(w, r)
+		return
+	}
+
+	log.Printf("🔍 404 %s", urlPath)
+	s.setCSPHeader(w, nonce)
+	w.Header().Set("Content-Type", s.pageContentType(buf.String()))
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, s.injectLiveReload(buf.String(), nonce))
+}
+
+// renderConventionNotFound renders the 404 template as a page inside the layout. Unlike
+// regular pages there is no retry without the layout: a failure means the plain 404.
+func (s *DevServer) renderConventionNotFound(buf *bytes.Buffer, urlPath, nonce string) error {
+	t, err := s.loadTemplates(s.cfg.NotFoundFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	site := s.site
+	s.mu.RUnlock()
+	rd := s.buildRenderData(&Page{Path: urlPath, File: s.cfg.NotFoundFile, Title: notFoundTitle}, site, urlPath, "", s.cfg.NotFoundFile)
+	rd.Nonce = nonce
+
+	if layoutName := s.resolveLayoutName(); layoutName != "" {
+		return s.executeTemplate(buf, t, layoutName, rd)
+	}
+	return s.executeTemplate(buf, t, "", rd)
+}
+
+// renderContextNotFound renders the 404 template through the entry layout when it fills
+// a page block, or on its own when it is a standalone document
+func (s *DevServer) renderContextNotFound(buf *bytes.Buffer, urlPath, nonce string) error {
+	content, err := s.readFile(s.cfg.NotFoundFile)
+	if err != nil {
+		return err
+	}
+	tmpl, err := s.loadContextTemplates(s.cfg.NotFoundFile)
+	if err != nil {
+		return err
+	}
+
+	data := s.buildContextRenderData(urlPath, &ContextPage{URLPath: urlPath, FilePath: s.cfg.NotFoundFile, Title: notFoundTitle}, nil)
+	if nonce != "" {
+		data["_cspNonce"] = nonce
+	}
+	name := filepath.Base(s.cfg.NotFoundFile)
+	if s.isContentPage(string(content)) {
+		name = filepath.Base(s.cfg.EntryFile)
+	}
+	return s.executeTemplate(buf, tmpl, name, data)
+}
+
+// executeTemplate runs the named template (the set's root when name is "") with
+// html/template's contextual escaping, or in text mode as text/template over the same
+// parse trees. The html/template set is never executed in text mode, so its trees are
+// never rewritten by the escaper and can be shared through the template cache.
+func (s *DevServer) executeTemplate(w io.Writer, tmpl *template.Template, name string, data any) error {
+	if !s.cfg.TextMode {
+		if name == "" {
+			return tmpl.Execute(w, data)
+		}
+		return tmpl.ExecuteTemplate(w, name, data)
+	}
+
+	text := texttemplate.New(tmpl.Name()).Funcs(texttemplate.FuncMap(s.funcMap()))
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if _, err := text.AddParseTree(t.Name(), t.Tree); err != nil {
+			return err
+		}
+	}
+	if name == "" {
+		return text.Execute(w, data)
+	}
+	return text.ExecuteTemplate(w, name, data)
+}
+
+// pageContentType is text/html, except in text mode for output that isn't HTML
+func (s *DevServer) pageContentType(output string) string {
+	if s.cfg.TextMode && !looksLikeHTML(output) {
+		return "text/plain; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// looksLikeHTML reports whether output sniffs as an HTML document
+func looksLikeHTML(output string) bool {
+	return strings.HasPrefix(http.DetectContentType([]byte(output)), "text/html")
+}
+
+func (s *DevServer) resolveLayoutName() string {
+	if !dirExists(s.cfg.LayoutsDir) {
+		return ""
+	}
+	if s.cfg.LayoutFile != "" {
+		layoutPath := filepath.Join(s.cfg.LayoutsDir, s.cfg.LayoutFile)
+		if fileExistsServe(layoutPath) {
+			return s.cfg.LayoutFile
+		}
+	}
+	entries, err := os.ReadDir(s.cfg.LayoutsDir)
 	if err != nil {
 		return ""
 	}
@@ -1342,9 +2569,11 @@ func (s *DevServer) resolveTemplatePath(urlPath string) string {
 		return exact
 	}
 
-	indexPath := filepath.Join(s.cfg.PagesDir, clean, "index.html")
-	if fileExistsServe(indexPath) {
-		return indexPath
+	for _, name := range s.cfg.indexNames() {
+		indexPath := filepath.Join(s.cfg.PagesDir, clean, name)
+		if fileExistsServe(indexPath) {
+			return indexPath
+		}
 	}
 
 	// Wildcard match
@@ -1365,20 +2594,22 @@ func (s *DevServer) resolveTemplatePath(urlPath string) string {
 
 func (s *DevServer) buildRenderData(page *Page, site Site, urlPath, slug, templateFile string) RenderData {
 	rd := RenderData{
-		Site: site,
-		Env:  getEnvMap(),
-		Dev:  true,
-		Slug: slug,
-		Path: urlPath,
-		Data: make(map[string]any),
+		Site:   site,
+		Env:    s.envMap(),
+		Dev:    !s.exporting,
+		Slug:   slug,
+		Path:   s.withPrefix(urlPath),
+		Prefix: s.cfg.Prefix,
+		Data:   make(map[string]any),
 	}
 	if page != nil {
 		rd.Page = *page
+		rd.Page.Path = s.withPrefix(page.Path)
 		for k, v := range page.Data {
 			rd.Data[k] = v
 		}
 	} else {
-		rd.Page = Page{Path: urlPath, File: templateFile}
+		rd.Page = Page{Path: rd.Path, File: templateFile}
 	}
 	return rd
 }
@@ -1386,14 +2617,13 @@ func (s *DevServer) buildRenderData(page *Page, site Site, urlPath, slug, templa
 // ── Template loading ────────────────────────────────────────────────────────
 
 func (s *DevServer) loadTemplates(pageFile string) (*template.Template, error) {
-	tmpl := template.New("").Funcs(serveFuncMap())
+	tmpl := template.New("").Delims(s.cfg.delims()).Funcs(s.funcMap())
 
 	// Parse layouts
 	if dirExists(s.cfg.LayoutsDir) {
 		layoutFiles, err := filepath.Glob(filepath.Join(s.cfg.LayoutsDir, "*.html"))
 		if err == nil && len(layoutFiles) > 0 {
-			tmpl, err = tmpl.ParseFiles(layoutFiles...)
-			if err != nil {
+			if err := s.parseFiles(tmpl, layoutFiles); err != nil {
 				return nil, fmt.Errorf("failed to parse layouts: %w", err)
 			}
 		}
@@ -1403,8 +2633,7 @@ func (s *DevServer) loadTemplates(pageFile string) (*template.Template, error) {
 	if dirExists(s.cfg.PartialsDir) {
 		partialFiles, err := filepath.Glob(filepath.Join(s.cfg.PartialsDir, "*.html"))
 		if err == nil && len(partialFiles) > 0 {
-			tmpl, err = tmpl.ParseFiles(partialFiles...)
-			if err != nil {
+			if err := s.parseFiles(tmpl, partialFiles); err != nil {
 				return nil, fmt.Errorf("failed to parse partials: %w", err)
 			}
 		}
@@ -1412,7 +2641,7 @@ func (s *DevServer) loadTemplates(pageFile string) (*template.Template, error) {
 
 	// Parse the page template
 	if pageFile != "" {
-		content, err := os.ReadFile(pageFile)
+		content, err := s.readFile(pageFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read page %s: %w", pageFile, err)
 		}
@@ -1425,90 +2654,586 @@ func (s *DevServer) loadTemplates(pageFile string) (*template.Template, error) {
 	return tmpl, nil
 }
 
-func serveFuncMap() template.FuncMap {
-	return template.FuncMap{
-		// String manipulation
-		"upper":     strings.ToUpper,
-		"lower":     strings.ToLower,
-		"title":     serveTitleCase,
-		"contains":  strings.Contains,
-		"replace":   strings.ReplaceAll,
-		"trim":      strings.TrimSpace,
-		"split":     strings.Split,
-		"join":      strings.Join,
-		"hasPrefix": strings.HasPrefix,
-		"hasSuffix": strings.HasSuffix,
-
-		// Comparison (flexible for JSON float64 vs int)
-		"eq": flexibleEq,
-		"ne": flexibleNe,
-		"lt": flexibleLt,
-		"le": flexibleLe,
-		"gt": flexibleGt,
-		"ge": flexibleGe,
-
-		// Conditional helpers
-		"default": func(def, val any) any {
-			if val == nil {
-				return def
+// loadContextTemplates builds the context-mode template set: every shared file
+// (layout, partials) plus the page template, the one with {{define "content"}}.
+// Missing or unreadable shared files are skipped with a warning.
+func (s *DevServer) loadContextTemplates(pageFile string) (*template.Template, error) {
+	tmpl := template.New("").Delims(s.cfg.delims()).Funcs(s.funcMap())
+
+	for _, file := range s.sharedFiles {
+		if !fileExistsServe(file) {
+			log.Printf("⚠️  Shared file not found: %s", file)
+			continue
+		}
+		content, err := s.readFile(file)
+		if err != nil {
+			log.Printf("⚠️  Failed to read shared file %s: %v", file, err)
+			continue
+		}
+		if _, err := tmpl.New(filepath.Base(file)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	if pageFile != "" && fileExistsServe(pageFile) {
+		content, err := s.readFile(pageFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %s: %w", pageFile, err)
+		}
+		if _, err := tmpl.New(filepath.Base(pageFile)).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(pageFile), err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+// parseFiles parses each file as a template named by its basename, like
+// template.ParseFiles, but reads through the snapshot when it is enabled.
+func (s *DevServer) parseFiles(tmpl *template.Template, files []string) error {
+	for _, file := range files {
+		content, err := s.readFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := tmpl.New(filepath.Base(file)).Parse(string(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ── Template cache ──────────────────────────────────────────────────────────
+
+// cachedTemplates returns the parsed template set for pageFile, built by load. With
+// caching off every request parses fresh; with it on, sets are kept until the next
+// file change. Parse errors are not cached, so a fix is picked up on the next request.
+func (s *DevServer) cachedTemplates(pageFile string, load func(string) (*template.Template, error)) (*template.Template, error) {
+	if !s.cfg.CacheTemplates && !s.cfg.Preload {
+		return load(pageFile)
+	}
+	s.templateCacheMu.Lock()
+	defer s.templateCacheMu.Unlock()
+	if tmpl, ok := s.templateCache[pageFile]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := load(pageFile)
+	if err != nil {
+		return nil, err
+	}
+	s.templateCache[pageFile] = tmpl
+	return tmpl, nil
+}
+
+func (s *DevServer) clearTemplateCache() {
+	s.templateCacheMu.Lock()
+	s.templateCache = make(map[string]*template.Template)
+	s.templateCacheMu.Unlock()
+}
+
+// preloadTemplates parses every discovered page with the shared templates into the
+// cache, so the first request is fast and broken templates are logged right away
+// rather than on navigation. It returns the number of pages that failed to parse.
+func (s *DevServer) preloadTemplates() int {
+	start := time.Now()
+	var files []string
+	load := s.loadTemplates
+	if s.contextMode {
+		load = s.loadContextTemplates
+		s.contextPageMu.RLock()
+		for _, p := range s.contextPages {
+			files = append(files, p.FilePath)
+		}
+		s.contextPageMu.RUnlock()
+		if len(files) == 0 {
+			files = append(files, "")
+		}
+	} else {
+		s.mu.RLock()
+		root := s.root
+		s.mu.RUnlock()
+		var walk func(p *Page)
+		walk = func(p *Page) {
+			if p == nil {
+				return
 			}
-			if s, ok := val.(string); ok && s == "" {
-				return def
+			if p.File != "" {
+				files = append(files, p.File)
 			}
-			return val
-		},
-
-		// Navigation helpers
-		"isActive": func(current, target string) bool {
-			current = strings.TrimSuffix(current, "/")
-			target = strings.TrimSuffix(target, "/")
-			if current == "" {
-				current = "/"
+			for _, child := range p.Children {
+				walk(child)
 			}
-			if target == "" {
-				target = "/"
+		}
+		walk(root)
+	}
+
+	failed := 0
+	for _, file := range files {
+		if _, err := s.cachedTemplates(file, load); err != nil {
+			failed++
+			log.Printf("❌ Preload: %v", err)
+		}
+	}
+	log.Printf("🔥 Preloaded %d template set(s) in %s (%d with errors)", len(files)-failed, time.Since(start).Round(time.Millisecond), failed)
+	return failed
+}
+
+// funcMap returns commonFuncMap with the asset helpers resolved against the server
+// configuration.
+func (s *DevServer) funcMap() template.FuncMap {
+	return commonFuncMap(s)
+}
+
+// ── Icons ───────────────────────────────────────────────────────────────────
+
+// svgIcon returns the SVG named name (with or without .svg) from the icons directory as
+// trusted HTML. attrs are key/value pairs set on the root <svg> element, replacing any
+// existing value; "size" sets both width and height.
+func (s *DevServer) svgIcon(name string, attrs ...any) (template.HTML, error) {
+	if s.cfg.IconsDir == "" {
+		return "", fmt.Errorf("svgIcon %q: no iconsDir configured", name)
+	}
+	if len(attrs)%2 != 0 {
+		return "", fmt.Errorf("svgIcon %q: attributes must be key/value pairs", name)
+	}
+
+	svg, err := s.loadIcon(name)
+	if err != nil {
+		return "", err
+	}
+	return applySVGAttrs(svg, attrs), nil
+}
+
+// applySVGAttrs sets attrs (key/value pairs) on the root <svg> element; "size" sets
+// both width and height
+func applySVGAttrs(svg string, attrs []any) template.HTML {
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key := fmt.Sprint(attrs[i])
+		value := template.HTMLEscapeString(fmt.Sprint(attrs[i+1]))
+		if key == "size" {
+			svg = setSVGAttr(svg, "width", value)
+			svg = setSVGAttr(svg, "height", value)
+			continue
+		}
+		svg = setSVGAttr(svg, key, value)
+	}
+	return template.HTML(svg)
+}
+
+// loadIcon reads an icon through the cache.
+func (s *DevServer) loadIcon(name string) (string, error) {
+	s.iconCacheMu.Lock()
+	defer s.iconCacheMu.Unlock()
+	if svg, ok := s.iconCache[name]; ok {
+		return svg, nil
+	}
+
+	svg, err := readSVGIcon(s.cfg.IconsDir, name, s.readFile)
+	if err != nil {
+		return "", err
+	}
+	s.iconCache[name] = svg
+	return svg, nil
+}
+
+// readSVGIcon reads the icon name (with or without .svg) from iconsDir, without the
+// XML prolog so it can be inlined in HTML
+func readSVGIcon(iconsDir, name string, readFile func(string) ([]byte, error)) (string, error) {
+	file := name
+	if filepath.Ext(file) != ".svg" {
+		file += ".svg"
+	}
+	path := filepath.Join(iconsDir, filepath.FromSlash(file))
+	if rel, err := filepath.Rel(iconsDir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("svgIcon %q: name escapes the icons directory", name)
+	}
+	content, err := readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("svgIcon %q: %w", name, err)
+	}
+
+	svg := string(content)
+	if i := strings.Index(svg, "<svg"); i > 0 {
+		svg = svg[i:]
+	}
+	return strings.TrimSpace(svg), nil
+}
+
+func (s *DevServer) clearIconCache() {
+	s.iconCacheMu.Lock()
+	s.iconCache = make(map[string]string)
+	s.iconCacheMu.Unlock()
+}
+
+// setSVGAttr sets attr on the opening <svg> tag, replacing an existing value.
+func setSVGAttr(svg, attr, value string) string {
+	end := strings.Index(svg, ">")
+	if !strings.HasPrefix(svg, "<svg") || end < 0 {
+		return svg
+	}
+	tag, rest := svg[:end], svg[end:]
+	selfClosing := strings.HasSuffix(tag, "/")
+	tag = strings.TrimSuffix(tag, "/")
+
+	re := regexp.MustCompile(`\s` + regexp.QuoteMeta(attr) + `\s*=\s*("[^"]*"|'[^']*')`)
+	tag = re.ReplaceAllString(tag, "")
+	tag = strings.TrimRight(tag, " \t\n") + fmt.Sprintf(` %s="%s"`, attr, value)
+	if selfClosing {
+		tag += "/"
+	}
+	return tag + rest
+}
+
+// b64img reads an image from the static root and returns it as a base64 data: URL.
+func (s *DevServer) b64img(path string) (template.URL, error) {
+	root := s.cfg.StaticDir
+	if s.contextMode {
+		root = s.cfg.ContentRoot
+	}
+	return imageDataURL(root, path, s.readFile)
+}
+
+// imageDataURL reads path (site-absolute or relative) under root as a data: URL
+func imageDataURL(root, path string, readFile func(string) ([]byte, error)) (template.URL, error) {
+	full := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(path, "/")))
+	if rel, err := filepath.Rel(root, full); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("b64img %q: path escapes %s", path, root)
+	}
+	content, err := readFile(full)
+	if err != nil {
+		return "", fmt.Errorf("b64img %q: %w", path, err)
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(full))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(content)
+	}
+	return template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content)), nil
+}
+
+// ── Sample data ─────────────────────────────────────────────────────────────
+
+// handleSampleData returns the analyzer's suggested sample data for the page at ?path=,
+// so a UI can offer "fill with sample data" when no data file is linked.
+func (s *DevServer) handleSampleData(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Query().Get("path")
+	if urlPath == "" {
+		urlPath = "/"
+	}
+
+	entryFile, files := s.templateFilesForPath(urlPath)
+	if entryFile == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := s.sampleDataFor(entryFile, files)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Analysis error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(data)
+}
+
+// handleNav renders only the navigation template for ?path= (default "/") so SPA-style
+// pages can fetch the menu and inject it client-side. The fragment gets the same data a
+// full page render would, but no live-reload script.
+func (s *DevServer) handleNav(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Query().Get("path")
+	if urlPath == "" {
+		urlPath = "/"
+	}
+	navName := s.cfg.NavTemplate
+	if navName == "" {
+		navName = "nav"
+	}
+
+	var tmpl *template.Template
+	if s.contextMode {
+		tmpl = template.New("").Delims(s.cfg.delims()).Funcs(s.funcMap())
+		var files []string
+		for _, file := range s.sharedFiles {
+			if fileExistsServe(file) {
+				files = append(files, file)
 			}
-			return current == target
-		},
-		"isActivePrefix": func(current, target string) bool {
-			return strings.HasPrefix(current, target)
-		},
-
-		// HTML helpers
-		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
-		"safeAttr": func(s string) template.HTMLAttr { return template.HTMLAttr(s) },
-		"safeURL":  func(s string) template.URL { return template.URL(s) },
-		"safeCSS":  func(s string) template.CSS { return template.CSS(s) },
-		"safeJS":   func(s string) template.JS { return template.JS(s) },
-
-		// Map helpers
-		"dict": func(values ...any) map[string]any {
-			if len(values)%2 != 0 {
-				return nil
+		}
+		if err := s.parseFiles(tmpl, files); err != nil {
+			http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var err error
+		tmpl, err = s.loadTemplates("")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	data := s.pageRenderData(urlPath)
+
+	if tmpl.Lookup(navName) == nil {
+		http.Error(w, fmt.Sprintf("No %q template defined", navName), http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := s.executeTemplate(&buf, tmpl, navName, data); err != nil {
+		log.Printf("❌ Nav render error: %v", err)
+		http.Error(w, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(buf.Bytes())
+}
+
+// pageRenderData builds the data a full render of urlPath would receive: the merged
+// context data in context mode, or RenderData (with slug data) in convention mode.
+func (s *DevServer) pageRenderData(urlPath string) any {
+	if s.contextMode {
+		ctxPage := s.findContextPage(urlPath)
+		var pageData map[string]any
+		if ctxPage != nil && ctxPage.DataFile != "" {
+			pageData = loadJSONFile(ctxPage.DataFile)
+		}
+		return s.buildContextRenderData(urlPath, ctxPage, pageData)
+	}
+
+	s.mu.RLock()
+	root := s.root
+	site := s.site
+	s.mu.RUnlock()
+
+	page, slug := findPage(root, urlPath)
+	templateFile := s.resolveTemplatePath(urlPath)
+	if page != nil {
+		templateFile = page.File
+	}
+	rd := s.buildRenderData(page, site, urlPath, slug, templateFile)
+	if slug != "" {
+		for k, v := range loadSlugData(templateFile, slug) {
+			rd.Data[k] = v
+		}
+	}
+	return rd
+}
+
+// templateFilesForPath returns the entry template and the full file set used to render urlPath.
+func (s *DevServer) templateFilesForPath(urlPath string) (string, []string) {
+	if s.contextMode {
+		files := append([]string{}, s.sharedFiles...)
+		if page := s.findContextPage(urlPath); page != nil {
+			files = append(files, page.FilePath)
+		} else if urlPath != "/" {
+			return "", nil
+		}
+		return s.cfg.EntryFile, files
+	}
+
+	s.mu.RLock()
+	root := s.root
+	s.mu.RUnlock()
+
+	templateFile := s.resolveTemplatePath(urlPath)
+	if page, _ := findPage(root, urlPath); page != nil {
+		templateFile = page.File
+	}
+	if templateFile == "" {
+		return "", nil
+	}
+
+	files := []string{templateFile}
+	for _, dir := range []string{s.cfg.LayoutsDir, s.cfg.PartialsDir} {
+		if matches, err := filepath.Glob(filepath.Join(dir, "*.html")); err == nil {
+			files = append(files, matches...)
+		}
+	}
+	return templateFile, files
+}
+
+// sampleDataFor runs the analyzer over a file set and builds nested sample data from the
+// suggested variable values, caching the result until the next file change.
+func (s *DevServer) sampleDataFor(entryFile string, files []string) (map[string]any, error) {
+	key := entryFile + "\x00" + strings.Join(files, "\x00")
+
+	s.sampleCacheMu.Lock()
+	defer s.sampleCacheMu.Unlock()
+	if data, ok := s.sampleCache[key]; ok {
+		return data, nil
+	}
+
+	analyzer := NewTemplateAnalyzer(filepath.Dir(entryFile))
+	analyzer.SetDelims(s.cfg.delims())
+	graph, err := analyzer.Analyze(entryFile, files)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shorter paths first so parent containers exist before their fields are set
+	vars := append([]Variable{}, graph.Variables...)
+	sort.Slice(vars, func(i, j int) bool {
+		if len(vars[i].Path) != len(vars[j].Path) {
+			return len(vars[i].Path) < len(vars[j].Path)
+		}
+		return vars[i].Path < vars[j].Path
+	})
+
+	data := make(map[string]any)
+	for _, v := range vars {
+		if v.Type == "variable" || strings.HasPrefix(v.Path, "$") {
+			continue
+		}
+		setSampleValue(data, v.Path, v.Suggested)
+	}
+
+	s.sampleCache[key] = data
+	return data, nil
+}
+
+func (s *DevServer) clearSampleCache() {
+	s.sampleCacheMu.Lock()
+	s.sampleCache = make(map[string]map[string]any)
+	s.sampleCacheMu.Unlock()
+}
+
+// setSampleValue sets a dotted analyzer path like "Apps[0].Name" in data, creating
+// intermediate objects and single-item arrays. Existing containers are never
+// replaced by scalar leaves.
+func setSampleValue(data map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	current := data
+	for i, part := range parts {
+		isArray := strings.HasSuffix(part, "[0]")
+		name := strings.TrimSuffix(part, "[0]")
+		last := i == len(parts)-1
+
+		if last && !isArray {
+			switch current[name].(type) {
+			case map[string]any, []any:
+				// Keep the richer structure already built from deeper paths
+			default:
+				current[name] = normalizeSample(value)
 			}
-			m := make(map[string]any, len(values)/2)
-			for i := 0; i < len(values); i += 2 {
-				if key, ok := values[i].(string); ok {
-					m[key] = values[i+1]
-				}
+			return
+		}
+
+		if isArray {
+			items, ok := current[name].([]any)
+			if !ok || len(items) == 0 {
+				items = []any{map[string]any{}}
 			}
-			return m
-		},
+			item, ok := items[0].(map[string]any)
+			if !ok {
+				// Literal items (e.g., ["a","b"]) can't hold fields — switch to objects
+				item = map[string]any{}
+				items = []any{item}
+			}
+			current[name] = items
+			current = item
+			continue
+		}
+
+		next, ok := current[name].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			current[name] = next
+		}
+		current = next
+	}
+}
 
-		// Slice helpers
-		"slice": func(values ...any) []any { return values },
+// normalizeSample converts analyzer suggestion types into plain JSON-shaped values.
+func normalizeSample(value any) any {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	default:
+		return value
 	}
 }
 
+// ── Content-Security-Policy ─────────────────────────────────────────────────
+
+// defaultCSPPolicy is sent when cspHeader is enabled without a custom cspPolicy.
+const defaultCSPPolicy = "script-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'"
+
+// newResponseNonce returns a fresh random nonce, or "" when CSP support is disabled.
+// Sending a policy implies cspNonce, otherwise the policy would block live reload.
+func (s *DevServer) newResponseNonce() string {
+	if !s.cfg.CSPNonce && !s.cfg.CSPHeader && s.cfg.CSPPolicy == "" {
+		return ""
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("⚠️  Failed to generate CSP nonce: %v", err)
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// setCSPHeader sends the configured Content-Security-Policy header for a response.
+func (s *DevServer) setCSPHeader(w http.ResponseWriter, nonce string) {
+	if !s.cfg.CSPHeader && s.cfg.CSPPolicy == "" {
+		return
+	}
+	policy := s.cfg.CSPPolicy
+	if policy == "" {
+		policy = defaultCSPPolicy
+	}
+	w.Header().Set("Content-Security-Policy", strings.ReplaceAll(policy, "{nonce}", nonce))
+}
+
 // ── SSE live reload ─────────────────────────────────────────────────────────
 
-func (s *DevServer) injectLiveReload(html string) string {
-	script := `<script>
+func (s *DevServer) injectLiveReload(html, nonce string) string {
+	if s.exporting || (s.cfg.TextMode && !looksLikeHTML(html)) {
+		return html
+	}
+	openTag := "<script>"
+	if nonce != "" {
+		openTag = fmt.Sprintf(`<script nonce="%s">`, nonce)
+	}
+	script := openTag + `
 (function() {
-  const source = new EventSource('/__reload');
+  const source = new EventSource('` + s.cfg.Prefix + `/__reload');
+  function normalize(p) {
+    return p.length > 1 ? p.replace(/\/+$/, '') : p;
+  }
+  // Re-fetch same-origin stylesheets with a cache-busting query, dropping the old
+  // <link> once the new one loads so the page never renders unstyled
+  function swapStylesheets() {
+    document.querySelectorAll('link[rel="stylesheet"]').forEach(function(link) {
+      var url = new URL(link.href, window.location.href);
+      if (url.origin !== window.location.origin) return;
+      url.searchParams.set('__tv', Date.now());
+      var next = link.cloneNode();
+      next.href = url.toString();
+      next.onload = next.onerror = function() { link.remove(); };
+      link.after(next);
+    });
+  }
   source.onmessage = function(e) {
     if (e.data === 'reload') {
       window.location.reload();
+      return;
+    }
+    var ev;
+    try { ev = JSON.parse(e.data); } catch (err) { return; }
+    if (ev.css) {
+      swapStylesheets();
+      return;
+    }
+    // Only reload when the change affects every page or this one
+    if (ev.all || (ev.urls || []).indexOf(normalize(window.location.pathname)) !== -1) {
+      window.location.reload();
     }
   };
   source.onerror = function() {
@@ -1538,7 +3263,7 @@ func (s *DevServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	ch := make(chan struct{}, 1)
+	ch := make(chan reloadEvent, 16)
 
 	s.sseClientsMu.Lock()
 	s.sseClients[ch] = struct{}{}
@@ -1555,8 +3280,12 @@ func (s *DevServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	for {
 		select {
-		case <-ch:
-			fmt.Fprintf(w, "data: reload\n\n")
+		case ev := <-ch:
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				payload = []byte("reload")
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
@@ -1564,12 +3293,115 @@ func (s *DevServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *DevServer) notifyClients() {
+// reloadEvent tells live-reload clients what changed and which URLs it affects.
+type reloadEvent struct {
+	File string   `json:"file"`
+	URLs []string `json:"urls,omitempty"` // Affected page URLs (normalized, with prefix)
+	All  bool     `json:"all"`            // True when every page must reload (layouts, partials, data, nav changes)
+	CSS  bool     `json:"css,omitempty"`  // Only a static stylesheet changed: swap <link> hrefs instead of reloading
+}
+
+// reloadEventFor maps a file change to the page URLs it affects. Edits to a single page
+// template are scoped to that page; anything else (layouts, partials, data files, pages
+// being added or removed) can change every page and reloads all clients.
+func (s *DevServer) reloadEventFor(event fsnotify.Event) reloadEvent {
+	ev := reloadEvent{File: event.Name, All: true}
+	if !event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		return ev
+	}
+
+	if s.contextMode {
+		s.contextPageMu.RLock()
+		defer s.contextPageMu.RUnlock()
+		hasRoot := false
+		for _, page := range s.contextPages {
+			hasRoot = hasRoot || page.URLPath == "/"
+		}
+		for i, page := range s.contextPages {
+			if page.FilePath == event.Name || page.DataFile == event.Name {
+				ev.URLs = append(ev.URLs, s.reloadURL(page.URLPath))
+				// Without a root page, "/" renders the first page (see renderContextPage)
+				if i == 0 && !hasRoot {
+					ev.URLs = append(ev.URLs, s.reloadURL("/"))
+				}
+			}
+		}
+	} else if strings.HasSuffix(event.Name, ".html") {
+		s.mu.RLock()
+		root := s.root
+		s.mu.RUnlock()
+		if page := findPageByFile(root, event.Name); page != nil && !page.Dynamic {
+			ev.URLs = append(ev.URLs, s.reloadURL(page.Path))
+		}
+	}
+
+	if len(ev.URLs) > 0 {
+		ev.All = false
+	}
+	return ev
+}
+
+// staticRoots lists the directories served as static files: staticDir in convention
+// mode, the content root and the entry's assets dir in context mode
+func (s *DevServer) staticRoots() []string {
+	var dirs []string
+	if s.contextMode {
+		if s.cfg.ContentRoot != "" && dirExists(s.cfg.ContentRoot) {
+			dirs = append(dirs, s.cfg.ContentRoot)
+		}
+		if assetsDir := filepath.Join(filepath.Dir(s.cfg.EntryFile), "assets"); dirExists(assetsDir) {
+			dirs = append(dirs, assetsDir)
+		}
+	} else if dirExists(s.cfg.StaticDir) {
+		dirs = append(dirs, s.cfg.StaticDir)
+	}
+	return dirs
+}
+
+// isStaticStylesheet reports whether a changed file is a .css file under a static root
+func (s *DevServer) isStaticStylesheet(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".css") {
+		return false
+	}
+	for _, dir := range s.staticRoots() {
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadURL converts a page path into the form the live-reload client compares against.
+func (s *DevServer) reloadURL(urlPath string) string {
+	u := s.withPrefix(urlPath)
+	if len(u) > 1 {
+		u = strings.TrimSuffix(u, "/")
+	}
+	return u
+}
+
+// findPageByFile returns the nav tree page rendered from the given template file.
+func findPageByFile(node *Page, file string) *Page {
+	if node == nil {
+		return nil
+	}
+	if node.File == file {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := findPageByFile(child, file); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (s *DevServer) notifyClients(ev reloadEvent) {
 	s.sseClientsMu.Lock()
 	defer s.sseClientsMu.Unlock()
 	for ch := range s.sseClients {
 		select {
-		case ch <- struct{}{}:
+		case ch <- ev:
 		default:
 		}
 	}
@@ -1577,7 +3409,7 @@ func (s *DevServer) notifyClients() {
 
 // ── Helpers ─────────────────────────────────────────────────────────────────
 
-func autoDetectIndex(pagesDir string) string {
+func autoDetectIndex(pagesDir string, indexNames []string) string {
 	entries, err := os.ReadDir(pagesDir)
 	if err != nil {
 		return ""
@@ -1591,14 +3423,87 @@ func autoDetectIndex(pagesDir string) string {
 	if len(candidates) == 0 {
 		return ""
 	}
-	for _, c := range candidates {
-		if c == "index.html" {
-			return c
+	for _, name := range indexNames {
+		for _, c := range candidates {
+			if c == name {
+				return c
+			}
 		}
 	}
 	return candidates[0]
 }
 
+// indexNames returns the configured directory index filenames, defaulting to index.html.
+func (c ServeConfig) indexNames() []string {
+	if len(c.IndexNames) == 0 {
+		return []string{"index.html"}
+	}
+	return c.IndexNames
+}
+
+// pageBlocks returns the block names that mark a template as a page.
+func (c ServeConfig) pageBlocks() []string {
+	if len(c.PageBlocks) == 0 {
+		return defaultPageBlocks
+	}
+	return c.PageBlocks
+}
+
+// delims returns the configured action delimiters, defaulting to {{ and }}.
+func (c ServeConfig) delims() (string, string) {
+	left, right, _ := parseDelims(c.Delims)
+	return defaultDelims(left, right)
+}
+
+// isIndexName reports whether a filename is one of the directory index names.
+func isIndexName(base string, indexNames []string) bool {
+	for _, name := range indexNames {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePrefix cleans a mount prefix to the form "/docs" ("" for the root).
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// withPrefix prepends the mount prefix to a site-absolute URL path.
+func (s *DevServer) withPrefix(urlPath string) string {
+	if s.cfg.Prefix == "" || !strings.HasPrefix(urlPath, "/") {
+		return urlPath
+	}
+	if urlPath == "/" {
+		return s.cfg.Prefix + "/"
+	}
+	return s.cfg.Prefix + urlPath
+}
+
+// prefixPages returns a deep copy of the nav tree with every path mounted under prefix.
+func prefixPages(pages []*Page, prefix string) []*Page {
+	if prefix == "" {
+		return pages
+	}
+	result := make([]*Page, 0, len(pages))
+	for _, p := range pages {
+		cp := *p
+		if cp.Path == "/" {
+			cp.Path = prefix + "/"
+		} else {
+			cp.Path = prefix + cp.Path
+		}
+		cp.Children = prefixPages(p.Children, prefix)
+		result = append(result, &cp)
+	}
+	return result
+}
+
 func getEnvMap() map[string]string {
 	env := make(map[string]string)
 	for _, e := range os.Environ() {
@@ -1611,6 +3516,53 @@ func getEnvMap() map[string]string {
 	return env
 }
 
+// envMap returns the variables exposed as .Env: EnvFile entries (each overridden by a
+// process variable of the same name), then TEMPLATEDEV_* variables. The file is reread
+// on every render, so edits show up on the next reload.
+func (s *DevServer) envMap() map[string]string {
+	env := make(map[string]string)
+	if s.cfg.EnvFile != "" {
+		content, err := s.readFile(s.cfg.EnvFile)
+		if err != nil {
+			log.Printf("⚠️  Failed to read env file %s: %v", s.cfg.EnvFile, err)
+		}
+		for key, value := range parseEnvFile(string(content)) {
+			if processValue, ok := os.LookupEnv(key); ok {
+				value = processValue
+			}
+			env[key] = value
+		}
+	}
+	for key, value := range getEnvMap() {
+		env[key] = value
+	}
+	return env
+}
+
+// parseEnvFile parses .env content: KEY=VALUE lines with an optional "export " prefix
+// and optionally quoted values. Blank lines and # comments are skipped.
+func parseEnvFile(content string) map[string]string {
+	env := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env
+}
+
 func fileExistsServe(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && !info.IsDir()
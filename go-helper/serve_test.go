@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TestBlockDefaultFallback renders a page that fills "content" but not "sidebar":
+// the layout's {{block "sidebar"}} default must still appear, in both modes
+func TestBlockDefaultFallback(t *testing.T) {
+	const layout = `<main>{{block "content" .}}default content{{end}}</main>` +
+		`<aside>{{block "sidebar" .}}default sidebar{{end}}</aside>`
+	const page = `{{define "content"}}page content{{end}}`
+
+	t.Run("context", func(t *testing.T) {
+		dir := t.TempDir()
+		base := writeTestFile(t, dir, "base.html", layout)
+		index := writeTestFile(t, dir, "pages/index.html", page)
+
+		s, err := newDevServer(ServeConfig{
+			ContextFiles: []string{base, index},
+			EntryFile:    base,
+			ContentRoot:  dir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, found, err := s.renderContextPage("/", "", nil)
+		if err != nil || !found {
+			t.Fatalf("render: found=%v err=%v", found, err)
+		}
+		assertBlocks(t, out)
+	})
+
+	t.Run("convention", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "layouts/base.html", layout)
+		writeTestFile(t, dir, "pages/index.html", page)
+
+		s, err := newDevServer(ServeConfig{
+			PagesDir:   filepath.Join(dir, "pages"),
+			LayoutsDir: filepath.Join(dir, "layouts"),
+			LayoutFile: "base.html",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, found, err := s.renderConventionPage("/", "", nil)
+		if err != nil || !found {
+			t.Fatalf("render: found=%v err=%v", found, err)
+		}
+		assertBlocks(t, out)
+	})
+}
+
+func assertBlocks(t *testing.T, out string) {
+	t.Helper()
+	if !strings.Contains(out, "<main>page content</main>") {
+		t.Errorf("page's content block missing:\n%s", out)
+	}
+	if !strings.Contains(out, "<aside>default sidebar</aside>") {
+		t.Errorf("sidebar block default missing:\n%s", out)
+	}
+}
+
+func TestImageDataURL(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "static")
+	writeTestFile(t, root, "img/dot.png", "png")
+	writeTestFile(t, dir, "secret.txt", "secret")
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"relative", "img/dot.png", "data:image/png;base64,cG5n", false},
+		{"site-absolute", "/img/dot.png", "data:image/png;base64,cG5n", false},
+		{"escapes root", "../secret.txt", "", true},
+		{"escapes from site root", "/../secret.txt", "", true},
+		{"escapes through a subdirectory", "img/../../secret.txt", "", true},
+		{"missing file", "img/none.png", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imageDataURL(root, tt.path, os.ReadFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("imageDataURL(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("imageDataURL(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNotFoundPage requests a missing URL with a NotFoundFile configured: the 404
+// template renders inside the layout with status 404 and its own page title
+func TestNotFoundPage(t *testing.T) {
+	const layout = `<title>{{.Page.Title}}</title><main>{{block "content" .}}{{end}}</main>`
+	const page = `{{define "content"}}home{{end}}`
+	const notFound = `{{define "content"}}missing{{end}}`
+
+	t.Run("context", func(t *testing.T) {
+		dir := t.TempDir()
+		base := writeTestFile(t, dir, "site/base.html", layout)
+		index := writeTestFile(t, dir, "site/pages/index.html", page)
+
+		s, err := newDevServer(ServeConfig{
+			ContextFiles: []string{base, index},
+			EntryFile:    base,
+			ContentRoot:  filepath.Join(dir, "site"),
+			NotFoundFile: writeTestFile(t, dir, "404.html", notFound),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		s.handleContextPage(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+		assertNotFoundPage(t, w)
+	})
+
+	t.Run("convention", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "layouts/base.html", layout)
+		writeTestFile(t, dir, "pages/index.html", page)
+
+		s, err := newDevServer(ServeConfig{
+			PagesDir:     filepath.Join(dir, "pages"),
+			LayoutsDir:   filepath.Join(dir, "layouts"),
+			LayoutFile:   "base.html",
+			NotFoundFile: writeTestFile(t, dir, "404.html", notFound),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		s.handleConventionPage(w, httptest.NewRequest(http.MethodGet, "/nope", nil), "/nope")
+		assertNotFoundPage(t, w)
+	})
+}
+
+func assertNotFoundPage(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<title>Not Found</title>") {
+		t.Errorf("404 page title missing:\n%s", body)
+	}
+	if !strings.Contains(body, "<main>missing</main>") {
+		t.Errorf("404 template content missing:\n%s", body)
+	}
+}
+
+// TestReloadEventRootFallback edits the page "/" falls back to when there is no root
+// page: tabs open on "/" must reload along with the page's own URL
+func TestReloadEventRootFallback(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base.html", `<main>{{block "content" .}}{{end}}</main>`)
+	about := writeTestFile(t, dir, "pages/about.html", `{{define "content"}}about{{end}}`)
+
+	s, err := newDevServer(ServeConfig{
+		ContextFiles: []string{base, about},
+		EntryFile:    base,
+		ContentRoot:  dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, found, err := s.renderContextPage("/", "", nil); err != nil || !found || !strings.Contains(out, "about") {
+		t.Fatalf("/ should render the first page: found=%v err=%v\n%s", found, err, out)
+	}
+
+	ev := s.reloadEventFor(fsnotify.Event{Name: about, Op: fsnotify.Write})
+	if ev.All {
+		t.Fatalf("page edit should be scoped, got %+v", ev)
+	}
+	if !slices.Contains(ev.URLs, "/") || !slices.Contains(ev.URLs, "/about") {
+		t.Errorf("URLs = %q, want \"/about\" and \"/\"", ev.URLs)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnapshotCase is one *.case.json file. Relative paths resolve against the case
+// file's directory.
+type SnapshotCase struct {
+	Entry     string          `json:"entry"`               // Entry template
+	Files     []string        `json:"files,omitempty"`     // Templates to include (empty: auto-discover in workspace)
+	Workspace string          `json:"workspace,omitempty"` // Workspace for auto-discovery (default: case dir)
+	Template  string          `json:"template,omitempty"`  // Template name to execute (default: entry)
+	Data      json.RawMessage `json:"data,omitempty"`      // Inline JSON object, or a path to a data file
+	Expected  string          `json:"expected,omitempty"`  // Expected output (default: <name>.expected<entry ext>)
+}
+
+// snapshotMaxDiffLines caps the diff printed for a failing case
+const snapshotMaxDiffLines = 40
+
+// runSnapshot renders every *.case.json in dir and compares the output with the
+// case's expected file, printing PASS/FAIL per case and a diff for failures. With
+// update, expected files are (re)written from the current output instead. It reports
+// whether every case passed.
+func runSnapshot(dir string, update bool) (bool, error) {
+	cases, err := filepath.Glob(filepath.Join(dir, "*.case.json"))
+	if err != nil {
+		return false, err
+	}
+	if len(cases) == 0 {
+		return false, fmt.Errorf("no *.case.json files in %s", dir)
+	}
+	sort.Strings(cases)
+
+	passed, failed, updated := 0, 0, 0
+	for _, casePath := range cases {
+		name := strings.TrimSuffix(filepath.Base(casePath), ".case.json")
+		actual, expectedPath, err := renderSnapshotCase(casePath, name)
+		if err != nil {
+			fmt.Printf("FAIL  %s: %v\n", name, err)
+			failed++
+			continue
+		}
+
+		if update {
+			if err := os.WriteFile(expectedPath, []byte(actual), 0644); err != nil {
+				return false, fmt.Errorf("%s: failed to write %s: %w", name, expectedPath, err)
+			}
+			fmt.Printf("UPDATE %s → %s\n", name, expectedPath)
+			updated++
+			continue
+		}
+
+		expected, err := os.ReadFile(expectedPath)
+		if err != nil {
+			fmt.Printf("FAIL  %s: %v (run with -update to create it)\n", name, err)
+			failed++
+			continue
+		}
+		if string(expected) == actual {
+			fmt.Printf("PASS  %s\n", name)
+			passed++
+			continue
+		}
+		fmt.Printf("FAIL  %s: output differs from %s\n", name, expectedPath)
+		fmt.Print(lineDiff(string(expected), actual, snapshotMaxDiffLines))
+		failed++
+	}
+
+	if update {
+		fmt.Printf("\n%d updated, %d failed\n", updated, failed)
+	} else {
+		fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	}
+	return failed == 0, nil
+}
+
+// renderSnapshotCase loads a case file and renders it through the same path as the
+// render command. It returns the output and the resolved expected-output path.
+func renderSnapshotCase(casePath, name string) (string, string, error) {
+	raw, err := os.ReadFile(casePath)
+	if err != nil {
+		return "", "", err
+	}
+	var c SnapshotCase
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return "", "", fmt.Errorf("invalid case file: %v", err)
+	}
+	if c.Entry == "" {
+		return "", "", fmt.Errorf("case has no entry")
+	}
+
+	caseDir := filepath.Dir(casePath)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(caseDir, p)
+	}
+
+	entry := resolve(c.Entry)
+	files := make([]string, len(c.Files))
+	for i, f := range c.Files {
+		files[i] = resolve(f)
+	}
+	workspace := resolve(c.Workspace)
+	if workspace == "" {
+		workspace = caseDir
+	}
+	expectedPath := resolve(c.Expected)
+	if expectedPath == "" {
+		expectedPath = filepath.Join(caseDir, name+".expected"+filepath.Ext(entry))
+	}
+
+	// data is either an inline object or a string naming a data file
+	var data map[string]interface{}
+	var dataFile string
+	if len(c.Data) > 0 && json.Unmarshal(c.Data, &dataFile) == nil {
+		if data, err = loadDataArg(resolve(dataFile)); err != nil {
+			return "", "", err
+		}
+	} else if len(c.Data) > 0 {
+		if err := json.Unmarshal(c.Data, &data); err != nil {
+			return "", "", fmt.Errorf("invalid data: %v", err)
+		}
+	}
+
+	output, err := NewTemplateRenderer(workspace).Render(entry, data, c.Template, files)
+	if err != nil {
+		return "", "", err
+	}
+	return output, expectedPath, nil
+}
+
+// lineDiff returns the lines that differ between expected and actual as "-"/"+" lines
+// with their line numbers, based on the longest common subsequence of lines. Output is
+// cut off after maxLines changed lines.
+func lineDiff(expected, actual string, maxLines int) string {
+	a := strings.Split(expected, "\n")
+	b := strings.Split(actual, "\n")
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	written := 0
+	emit := func(prefix string, line int, text string) bool {
+		if written == maxLines {
+			out.WriteString("      ... (diff truncated)\n")
+			written++
+			return false
+		}
+		if written > maxLines {
+			return false
+		}
+		fmt.Fprintf(&out, "  %s%4d| %s\n", prefix, line, text)
+		written++
+		return true
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			if !emit("-", i+1, a[i]) {
+				return out.String()
+			}
+			i++
+		default:
+			if !emit("+", j+1, b[j]) {
+				return out.String()
+			}
+			j++
+		}
+	}
+	return out.String()
+}
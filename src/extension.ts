@@ -80,6 +80,7 @@ ${buildCmdFromRoot}
 		const info: ServerInfoData = {
 			mode: isContextMode ? 'context' : 'convention',
 			port: serverProvider.getPort(),
+			url: serverProvider.getUrl(),
 			// Context mode fields
 			entryFile: config.entryFile,
 			contextFiles: config.contextFiles,
@@ -34,6 +34,7 @@ export class DevServerProvider implements vscode.Disposable {
     private process: ChildProcess | undefined;
     private status: ServerStatus = 'stopped';
     private port: number = 0;
+    private scheme: 'http' | 'https' = 'http';
     private outputChannel: vscode.OutputChannel;
     private statusBarItem: vscode.StatusBarItem;
     private onStatusChangeCallback?: (status: ServerStatus, port?: number) => void;
@@ -80,6 +81,10 @@ export class DevServerProvider implements vscode.Disposable {
         return this.port;
     }
 
+    getUrl(): string {
+        return `${this.scheme}://localhost:${this.port}`;
+    }
+
     isRunning(): boolean {
         return this.status === 'running';
     }
@@ -143,13 +148,14 @@ export class DevServerProvider implements vscode.Disposable {
                 const text = data.toString();
                 this.outputChannel.append(text);
 
-                // Look for the SERVE_READY signal with port number
-                const match = text.match(/SERVE_READY\|port=(\d+)/);
+                // Look for the SERVE_READY signal with port number (and scheme=https under TLS)
+                const match = text.match(/SERVE_READY\|port=(\d+)(?:\|scheme=(https?))?/);
                 if (match) {
                     this.port = parseInt(match[1], 10);
+                    this.scheme = match[2] === 'https' ? 'https' : 'http';
                     this.setStatus('running');
                     this.notifyServerInfo();
-                    showTimedNotification(`Server running at http://localhost:${this.port}`);
+                    showTimedNotification(`Server running at ${this.getUrl()}`);
                 }
             });
 
@@ -229,8 +235,7 @@ export class DevServerProvider implements vscode.Disposable {
             showTimedNotification('Server is not running. Start it first.', 'warning');
             return;
         }
-        const url = `http://localhost:${this.port}`;
-        vscode.env.openExternal(vscode.Uri.parse(url));
+        vscode.env.openExternal(vscode.Uri.parse(this.getUrl()));
     }
 
     private buildConfig(): ServerConfig | undefined {
@@ -348,7 +353,7 @@ export class DevServerProvider implements vscode.Disposable {
                 break;
             case 'running':
                 this.statusBarItem.text = `$(debug-stop) Server :${this.port}`;
-                this.statusBarItem.tooltip = `Server running at http://localhost:${this.port}\nClick to stop`;
+                this.statusBarItem.tooltip = `Server running at ${this.getUrl()}\nClick to stop`;
                 this.statusBarItem.backgroundColor = new vscode.ThemeColor('statusBarItem.warningBackground');
                 break;
             case 'error':
@@ -857,6 +857,7 @@ class HtmxRequestItem extends vscode.TreeItem {
 export interface ServerInfoData {
     mode: 'context' | 'convention' | 'unknown';
     port: number;
+    url: string;
     // Context mode
     entryFile?: string;
     contextFiles?: string[];
@@ -910,7 +911,7 @@ export class ServerInfoProvider implements vscode.TreeDataProvider<ServerInfoIte
             // Server status
             items.push(new ServerInfoValueItem(
                 `Server :${info.port}`,
-                `http://localhost:${info.port}`,
+                info.url,
                 'radio-tower',
                 'charts.green'
             ));